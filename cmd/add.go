@@ -7,20 +7,23 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/vvb/backlog/models"
-	"github.com/vvb/backlog/storage"
 )
 
 var (
-	addDesc    string
-	addDueDate string
-	addTags    string
+	addDesc     string
+	addDueDate  string
+	addTags     string
+	addPriority string
+	addParent   string
+	addRepeat   string
 )
 
 var addCmd = &cobra.Command{
-	Use:   "add [title]",
-	Short: "Add a new backlog item",
-	Long:  `Add a new backlog item with title, description, due date, and tags.`,
-	Args:  cobra.ExactArgs(1),
+	Use:         "add [title]",
+	Short:       "Add a new backlog item",
+	Long:        `Add a new backlog item with title, description, due date, and tags.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: printsItems,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		title := args[0]
 
@@ -32,26 +35,46 @@ var addCmd = &cobra.Command{
 		}
 
 		// Parse tags
-		var tags []string
-		if addTags != "" {
-			tags = strings.Split(addTags, ",")
-			for i := range tags {
-				tags[i] = strings.TrimSpace(tags[i])
-			}
+		tags := splitTags(addTags)
+
+		// Validate recurrence rule if provided
+		repeat, err := parseRepeat(addRepeat)
+		if err != nil {
+			return err
 		}
 
-		// Create storage
-		store, err := storage.New()
+		// Validate priority if provided
+		priority, err := models.ParsePriority(addPriority)
+		if err != nil {
+			return err
+		}
+
+		// New items start in the first status of the board's workflow
+		workflow, err := boardWorkflow()
 		if err != nil {
 			return err
 		}
 
-		// Load existing backlog
-		backlog, err := store.Load()
+		// Create storage
+		store, err := openStoreForUpdate()
 		if err != nil {
 			return err
 		}
 
+		// Find the epic the item belongs to
+		var parent string
+		if addParent != "" {
+			backlog, err := store.Load()
+			if err != nil {
+				return err
+			}
+			i, err := models.Resolve(backlog.Items, addParent)
+			if err != nil {
+				return fmt.Errorf("parent: %w", err)
+			}
+			parent = backlog.Items[i].ID
+		}
+
 		// Generate ID
 		id := generateID()
 
@@ -62,20 +85,29 @@ var addCmd = &cobra.Command{
 			Description: addDesc,
 			DueDate:     addDueDate,
 			Tags:        tags,
-			Status:      models.StatusTodo,
+			Status:      workflow.Initial(),
+			Priority:    priority,
+			Parent:      parent,
+			Repeat:      repeat,
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
 		}
 
-		// Add to backlog
-		backlog.Items = append(backlog.Items, item)
-
 		// Save
-		if err := store.Save(backlog); err != nil {
+		if err := store.Put(item); err != nil {
 			return err
 		}
 
-		fmt.Printf("✓ Added backlog item: %s (ID: %s)\n", title, id)
+		// The store numbers new items
+		saved, err := store.Get(id)
+		if err != nil {
+			return err
+		}
+
+		if structuredOutput() {
+			return printItem(*saved)
+		}
+		fmt.Printf("✓ Added backlog item: %s (%s)\n", title, saved.Ref())
 		return nil
 	},
 }
@@ -84,6 +116,9 @@ func init() {
 	addCmd.Flags().StringVar(&addDesc, "desc", "", "Description of the backlog item")
 	addCmd.Flags().StringVar(&addDueDate, "due", "", "Due date in DD-MM-YYYY format")
 	addCmd.Flags().StringVar(&addTags, "tags", "", "Comma-separated tags")
+	addCmd.Flags().StringVar(&addPriority, "priority", "", "Priority (P0, P1, P2 or P3)")
+	addCmd.Flags().StringVar(&addParent, "parent", "", "ID of the epic the item belongs to")
+	addCmd.Flags().StringVar(&addRepeat, "repeat", "", "Repeat the item: daily, weekly, weekly:mon,thu, monthly or every:3d")
 }
 
 // isValidDateFormat checks if the date is in DD-MM-YYYY format
@@ -96,3 +131,15 @@ func isValidDateFormat(date string) bool {
 func generateID() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
 }
+
+// splitTags parses a comma-separated tag list, trimming whitespace around
+// each tag and dropping empty ones. An empty string yields no tags.
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
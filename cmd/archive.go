@@ -2,22 +2,47 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/vvb/backlog/models"
 	"github.com/vvb/backlog/storage"
 )
 
+var (
+	archiveOlderThan string
+	archiveTags      string
+	archiveIDs       string
+	archiveDryRun    bool
+
+	autoArchiveOlderThan string
+	autoArchiveTags      string
+	autoArchiveOff       bool
+)
+
 var archiveCmd = &cobra.Command{
 	Use:   "archive",
 	Short: "Archive completed items",
-	Long:  `Move all items with 'done' status to the archive file.`,
+	Long: `Move items in the board's done status to the archive file. By default every
+done item is archived; --older-than, --tag and --id narrow that down.`,
+	Annotations: printsItems,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		workflow, err := boardWorkflow()
+		if err != nil {
+			return err
+		}
+		rule, err := newArchiveRule(archiveOlderThan, splitTags(archiveTags), workflow.Done)
+		if err != nil {
+			return err
+		}
+
 		// Create storage
-		store, err := storage.New()
+		store, unlock, err := openLockedStore()
 		if err != nil {
 			return err
 		}
+		defer unlock()
 
 		// Load backlog
 		backlog, err := store.Load()
@@ -25,43 +50,395 @@ var archiveCmd = &cobra.Command{
 			return err
 		}
 
-		// Load archive
-		archive, err := store.LoadArchive()
-		if err != nil {
+		// Restrict to the given items, which must be done
+		if archiveIDs != "" {
+			rule.ids = map[string]bool{}
+			for _, ref := range splitTags(archiveIDs) {
+				i, err := models.Resolve(backlog.Items, ref)
+				if err != nil {
+					return err
+				}
+				item := backlog.Items[i]
+				if item.Status != rule.done {
+					return fmt.Errorf("item %s is not done", item.Ref())
+				}
+				rule.ids[item.ID] = true
+			}
+		}
+
+		selected := rule.selectItems(backlog.Items, time.Now())
+		if structuredOutput() && (archiveDryRun || len(selected) == 0) {
+			return printItems(selected)
+		}
+		if len(selected) == 0 {
+			fmt.Println("No completed items to archive")
+			return nil
+		}
+
+		if archiveDryRun {
+			fmt.Printf("Would archive %d completed item(s):\n", len(selected))
+			displayArchivedItems(selected)
+			return nil
+		}
+
+		if err := archiveItems(store, backlog, selected); err != nil {
 			return err
 		}
 
-		// Separate done items from active items
-		activeItems := []models.BacklogItem{}
-		archivedCount := 0
+		if structuredOutput() {
+			return printItems(selected)
+		}
+		fmt.Printf("✓ Archived %d completed item(s)\n", len(selected))
+		return nil
+	},
+}
+
+var archiveAutoCmd = &cobra.Command{
+	Use:   "auto",
+	Short: "Show or set the board's auto-archive policy",
+	Long: `Show or set the auto-archive policy of the current board. When a policy is
+set, done items completed longer ago than --older-than (and carrying all
+--tag tags, if given) are archived whenever a command changes the board or
+interactive mode starts. Commands that only read the board, such as list
+and search, never archive anything, so looking at a board never writes to
+it. An unarchived item's age counts from when it was unarchived.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := currentBoardDir()
+		if err != nil {
+			return err
+		}
+		config, err := storage.LoadBoardConfig(dir)
+		if err != nil {
+			return err
+		}
 
-		for _, item := range backlog.Items {
-			if item.Status == models.StatusDone {
-				archive.Items = append(archive.Items, item)
-				archivedCount++
+		switch {
+		case autoArchiveOff:
+			config.AutoArchive = nil
+		case autoArchiveOlderThan != "":
+			if _, err := parseAge(autoArchiveOlderThan); err != nil {
+				return err
+			}
+			config.AutoArchive = &storage.AutoArchive{
+				OlderThan: autoArchiveOlderThan,
+				Tags:      splitTags(autoArchiveTags),
+			}
+		default:
+			if config.AutoArchive == nil {
+				fmt.Println("Auto-archive is off")
 			} else {
-				activeItems = append(activeItems, item)
+				fmt.Printf("Auto-archive: done items completed more than %s ago%s\n",
+					config.AutoArchive.OlderThan, tagsSuffix(config.AutoArchive.Tags))
 			}
+			return nil
 		}
 
-		if archivedCount == 0 {
-			fmt.Println("No completed items to archive")
+		if err := storage.SaveBoardConfig(dir, config); err != nil {
+			return err
+		}
+
+		if config.AutoArchive == nil {
+			fmt.Println("✓ Auto-archive turned off")
+		} else {
+			fmt.Printf("✓ Auto-archiving done items completed more than %s ago%s\n",
+				config.AutoArchive.OlderThan, tagsSuffix(config.AutoArchive.Tags))
+		}
+		return nil
+	},
+}
+
+var archiveListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List archived items",
+	Annotations: printsItems,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Create storage
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+
+		// Load archive
+		archive, err := store.LoadArchive()
+		if err != nil {
+			return err
+		}
+
+		if structuredOutput() {
+			return printItems(archive.Items)
+		}
+		if len(archive.Items) == 0 {
+			fmt.Println("Archive is empty")
 			return nil
 		}
 
-		// Update backlog with only active items
-		backlog.Items = activeItems
+		fmt.Printf("\nArchive (%d item(s))\n\n", len(archive.Items))
+		displayArchivedItems(archive.Items)
+		fmt.Println()
+
+		return nil
+	},
+}
+
+var archiveShowCmd = &cobra.Command{
+	Use:         "show [id]",
+	Short:       "Show an archived item",
+	Args:        cobra.ExactArgs(1),
+	Annotations: printsItems,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Create storage
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+
+		// Load archive
+		archive, err := store.LoadArchive()
+		if err != nil {
+			return err
+		}
+
+		i, err := models.Resolve(archive.Items, args[0])
+		if err != nil {
+			return err
+		}
+
+		if structuredOutput() {
+			return printItem(archive.Items[i])
+		}
 
-		// Save both files
-		if err := store.Save(backlog); err != nil {
+		fmt.Println()
+		displayItem(archive.Items[i])
+		fmt.Println()
+		return nil
+	},
+}
+
+var unarchiveCmd = &cobra.Command{
+	Use:         "unarchive [id]",
+	Short:       "Move an archived item back to the board",
+	Args:        cobra.ExactArgs(1),
+	Annotations: printsItems,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Create storage
+		store, unlock, err := openLockedStore()
+		if err != nil {
 			return err
 		}
+		defer unlock()
 
-		if err := store.SaveArchive(archive); err != nil {
+		// Load backlog and archive
+		backlog, err := store.Load()
+		if err != nil {
+			return err
+		}
+		archive, err := store.LoadArchive()
+		if err != nil {
+			return err
+		}
+
+		i, err := models.Resolve(archive.Items, args[0])
+		if err != nil {
+			return err
+		}
+
+		item, err := unarchiveItem(store, backlog, archive, archive.Items[i].ID)
+		if err != nil {
 			return err
 		}
 
-		fmt.Printf("✓ Archived %d completed item(s)\n", archivedCount)
+		if structuredOutput() {
+			return printItem(*item)
+		}
+		fmt.Printf("✓ Unarchived backlog item: %s\n", item.Title)
 		return nil
 	},
 }
+
+func init() {
+	archiveCmd.Flags().StringVar(&archiveOlderThan, "older-than", "", "Only archive items completed longer ago than this (e.g. 14d)")
+	archiveCmd.Flags().StringVar(&archiveTags, "tag", "", "Only archive items with all of these comma-separated tags")
+	archiveCmd.Flags().StringVar(&archiveIDs, "id", "", "Only archive these comma-separated items")
+	archiveCmd.Flags().BoolVar(&archiveDryRun, "dry-run", false, "Show what would be archived without changing anything")
+
+	archiveAutoCmd.Flags().StringVar(&autoArchiveOlderThan, "older-than", "", "Archive done items completed longer ago than this (e.g. 14d)")
+	archiveAutoCmd.Flags().StringVar(&autoArchiveTags, "tag", "", "Only auto-archive items with all of these comma-separated tags")
+	archiveAutoCmd.Flags().BoolVar(&autoArchiveOff, "off", false, "Turn auto-archiving off")
+
+	archiveCmd.AddCommand(archiveListCmd)
+	archiveCmd.AddCommand(archiveShowCmd)
+	archiveCmd.AddCommand(archiveAutoCmd)
+}
+
+// archiveRule selects the done items to archive
+type archiveRule struct {
+	done      models.Status   // the workflow's done status
+	olderThan time.Duration   // completed at least this long ago; 0 means any
+	tags      []string        // carrying all of these tags
+	ids       map[string]bool // one of these items; nil means any
+}
+
+func newArchiveRule(olderThan string, tags []string, done models.Status) (archiveRule, error) {
+	rule := archiveRule{done: done, tags: tags}
+	if olderThan != "" {
+		age, err := parseAge(olderThan)
+		if err != nil {
+			return rule, err
+		}
+		rule.olderThan = age
+	}
+	return rule, nil
+}
+
+// selectItems returns the items the rule archives at the given time
+func (r archiveRule) selectItems(items []models.BacklogItem, now time.Time) []models.BacklogItem {
+	query := storage.Query{Statuses: []models.Status{r.done}, Tags: r.tags}
+
+	var selected []models.BacklogItem
+	for _, item := range items {
+		if !query.Matches(item) {
+			continue
+		}
+		if r.ids != nil && !r.ids[item.ID] {
+			continue
+		}
+		if r.olderThan > 0 && now.Sub(archivableSince(item, r.done)) < r.olderThan {
+			continue
+		}
+		selected = append(selected, item)
+	}
+	return selected
+}
+
+// archivableSince returns when a done item started aging towards being
+// archived: when it was completed, or when it was last unarchived if that
+// came later
+func archivableSince(item models.BacklogItem, done models.Status) time.Time {
+	since := item.CompletedAt(done)
+	if item.UnarchivedAt != nil && item.UnarchivedAt.After(since) {
+		since = *item.UnarchivedAt
+	}
+	return since
+}
+
+// archiveItems moves the selected items from backlog to the archive and
+// saves both, archive first so a failure in between never loses items.
+// Timers running on the items are stopped and their time logged. The
+// caller should hold the store lock.
+func archiveItems(store storage.Store, backlog *models.Backlog, selected []models.BacklogItem) error {
+	archive, err := store.LoadArchive()
+	if err != nil {
+		return err
+	}
+
+	move := map[string]bool{}
+	for _, item := range selected {
+		move[item.ID] = true
+	}
+
+	now := time.Now()
+	active := []models.BacklogItem{}
+	for _, item := range backlog.Items {
+		if move[item.ID] {
+			item.StopTimer(now)
+			archive.Items = append(archive.Items, item)
+		} else {
+			active = append(active, item)
+		}
+	}
+
+	if err := store.SaveArchive(archive); err != nil {
+		return err
+	}
+
+	backlog.Items = active
+	return store.Save(backlog)
+}
+
+// autoArchive applies the auto-archive policy of the board stored in dir,
+// if it has one
+func autoArchive(store storage.Store, dir string) error {
+	config, err := storage.LoadBoardConfig(dir)
+	if err != nil {
+		return err
+	}
+	policy := config.AutoArchive
+	if policy == nil {
+		return nil
+	}
+	rule, err := newArchiveRule(policy.OlderThan, policy.Tags, config.BoardWorkflow().Done)
+	if err != nil {
+		return fmt.Errorf("invalid auto-archive policy: %w", err)
+	}
+
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	backlog, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	selected := rule.selectItems(backlog.Items, time.Now())
+	if len(selected) == 0 {
+		return nil
+	}
+	return archiveItems(store, backlog, selected)
+}
+
+// tagsSuffix describes a tag restriction for messages
+func tagsSuffix(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return " tagged " + strings.Join(tags, ", ")
+}
+
+// unarchiveItem moves the item with the given ID from archive back to
+// backlog and saves both. The backlog is saved first so a failure in
+// between never loses the item. The item keeps its status and records when
+// it was unarchived, which restarts its auto-archive age. The caller should
+// hold the store lock.
+func unarchiveItem(store storage.Store, backlog, archive *models.Backlog, id string) (*models.BacklogItem, error) {
+	i := -1
+	for j := range archive.Items {
+		if archive.Items[j].ID == id {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return nil, fmt.Errorf("archived item with ID %s not found", id)
+	}
+	item := archive.Items[i]
+	now := time.Now()
+	item.UnarchivedAt = &now
+
+	backlog.Items = append(backlog.Items, item)
+	if err := store.Save(backlog); err != nil {
+		return nil, err
+	}
+
+	archive.Items = append(archive.Items[:i], archive.Items[i+1:]...)
+	if err := store.SaveArchive(archive); err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// displayArchivedItems prints one line per archived item. Items are only
+// archived from the done status, so the status they kept is the one that
+// completed them.
+func displayArchivedItems(items []models.BacklogItem) {
+	for _, item := range items {
+		line := fmt.Sprintf("%-6s %s", item.Ref(), item.Title)
+		if len(item.Tags) > 0 {
+			line += "  [" + strings.Join(item.Tags, ", ") + "]"
+		}
+		fmt.Printf("%s  (completed %s)\n", line, item.CompletedAt(item.Status).Format("02-01-2006 15:04"))
+	}
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vvb/backlog/models"
+)
+
+func TestUnarchivedItemIsNotArchivedAgain(t *testing.T) {
+	store := useMemoryStore(t)
+	completed := time.Now().AddDate(0, 0, -30)
+	item := models.BacklogItem{
+		ID:        "a",
+		Title:     "Shipped last month",
+		Status:    models.StatusDone,
+		CreatedAt: completed,
+		UpdatedAt: completed,
+		History:   []models.Change{{At: completed, Field: "status", Old: "todo", New: "done"}},
+	}
+	if err := store.SaveArchive(&models.Backlog{Items: []models.BacklogItem{item}}); err != nil {
+		t.Fatal(err)
+	}
+
+	run(t, "archive", "auto", "--older-than", "14d")
+	run(t, "unarchive", "a")
+
+	// The next write applies the policy, which must not take the item
+	// straight back to the archive
+	run(t, "add", "Another")
+
+	if _, err := store.Get("a"); err != nil {
+		t.Errorf("unarchived item is gone from the board after the next write: %v", err)
+	}
+	archive, err := store.LoadArchive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archive.Items) != 0 {
+		t.Errorf("archive = %+v, want it empty", archive.Items)
+	}
+}
+
+func TestArchiveRuleCountsAgeFromUnarchiving(t *testing.T) {
+	now := time.Now()
+	completed := now.AddDate(0, 0, -30)
+	unarchived := now.AddDate(0, 0, -20)
+	item := models.BacklogItem{
+		ID:           "a",
+		Status:       models.StatusDone,
+		UpdatedAt:    completed,
+		History:      []models.Change{{At: completed, Field: "status", New: "done"}},
+		UnarchivedAt: &unarchived,
+	}
+
+	tests := []struct {
+		olderThan time.Duration
+		want      int
+	}{
+		{14 * 24 * time.Hour, 1},
+		{25 * 24 * time.Hour, 0},
+	}
+	for _, tt := range tests {
+		rule := archiveRule{done: models.StatusDone, olderThan: tt.olderThan}
+		if got := len(rule.selectItems([]models.BacklogItem{item}, now)); got != tt.want {
+			t.Errorf("older than %s: selected %d item(s), want %d", tt.olderThan, got, tt.want)
+		}
+	}
+}
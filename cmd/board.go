@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/storage"
+)
+
+var boardDeleteForce bool
+
+var boardCmd = &cobra.Command{
+	Use:   "board",
+	Short: "Manage boards",
+	Long: `Manage named boards. Each board has its own items and archive.
+
+The board used by commands is chosen by the --board flag, then by the
+default set with 'backlog board switch', then the built-in "default" board.`,
+}
+
+var boardCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create a new board",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dataDir, err := storage.DataDir()
+		if err != nil {
+			return err
+		}
+
+		if err := storage.CreateBoard(dataDir, args[0]); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Created board: %s\n", args[0])
+		return nil
+	},
+}
+
+var boardListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all boards",
+	Long:  `List all boards. The board commands currently use is marked with '*'.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dataDir, err := storage.DataDir()
+		if err != nil {
+			return err
+		}
+
+		boards, err := storage.ListBoards(dataDir)
+		if err != nil {
+			return err
+		}
+
+		current, err := currentBoard(dataDir)
+		if err != nil {
+			return err
+		}
+
+		for _, board := range boards {
+			marker := " "
+			if board == current {
+				marker = "*"
+			}
+			fmt.Printf("%s %s\n", marker, board)
+		}
+		return nil
+	},
+}
+
+var boardSwitchCmd = &cobra.Command{
+	Use:   "switch [name]",
+	Short: "Set the default board",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		dataDir, err := storage.DataDir()
+		if err != nil {
+			return err
+		}
+
+		if !storage.BoardExists(dataDir, name) {
+			return fmt.Errorf("%w: %s", storage.ErrBoardNotFound, name)
+		}
+
+		config, err := storage.LoadConfig(dataDir)
+		if err != nil {
+			return err
+		}
+
+		config.DefaultBoard = name
+		if err := storage.SaveConfig(dataDir, config); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Switched to board: %s\n", name)
+		return nil
+	},
+}
+
+var boardRenameCmd = &cobra.Command{
+	Use:   "rename [old] [new]",
+	Short: "Rename a board",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		oldName, newName := args[0], args[1]
+
+		dataDir, err := storage.DataDir()
+		if err != nil {
+			return err
+		}
+
+		if err := storage.RenameBoard(dataDir, oldName, newName); err != nil {
+			return err
+		}
+
+		// Keep the remembered default pointing at the same board
+		config, err := storage.LoadConfig(dataDir)
+		if err != nil {
+			return err
+		}
+		if config.DefaultBoard == oldName {
+			config.DefaultBoard = newName
+			if err := storage.SaveConfig(dataDir, config); err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("✓ Renamed board %s to %s\n", oldName, newName)
+		return nil
+	},
+}
+
+var boardDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete a board and all of its items",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		dataDir, err := storage.DataDir()
+		if err != nil {
+			return err
+		}
+
+		if !storage.BoardExists(dataDir, name) {
+			return fmt.Errorf("%w: %s", storage.ErrBoardNotFound, name)
+		}
+
+		// Refuse to throw away items unless asked to
+		if !boardDeleteForce && name != storage.DefaultBoard {
+			store, err := storage.OpenBoard(storage.BoardDir(dataDir, name))
+			if err != nil {
+				return err
+			}
+			backlog, err := store.Load()
+			if err != nil {
+				return err
+			}
+			if len(backlog.Items) > 0 {
+				return fmt.Errorf("board %s has %d item(s); use --force to delete it anyway", name, len(backlog.Items))
+			}
+		}
+
+		if err := storage.DeleteBoard(dataDir, name); err != nil {
+			return err
+		}
+
+		// Fall back to the built-in default if this was the remembered one
+		config, err := storage.LoadConfig(dataDir)
+		if err != nil {
+			return err
+		}
+		if config.DefaultBoard == name {
+			config.DefaultBoard = ""
+			if err := storage.SaveConfig(dataDir, config); err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("✓ Deleted board: %s\n", name)
+		return nil
+	},
+}
+
+func init() {
+	boardDeleteCmd.Flags().BoolVar(&boardDeleteForce, "force", false, "Delete the board even if it still has items")
+
+	boardCmd.AddCommand(boardCreateCmd)
+	boardCmd.AddCommand(boardListCmd)
+	boardCmd.AddCommand(boardSwitchCmd)
+	boardCmd.AddCommand(boardRenameCmd)
+	boardCmd.AddCommand(boardDeleteCmd)
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/models"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Manage an item's checklist",
+	Long: `Break an item down into checklist entries and tick them off. Entries are
+numbered from 1 in the order they were added, as shown by 'backlog search'.`,
+}
+
+var checkAddCmd = &cobra.Command{
+	Use:   "add [id] [text]",
+	Short: "Add a checklist entry",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		text := strings.TrimSpace(args[1])
+		if text == "" {
+			return fmt.Errorf("checklist entry text is required")
+		}
+
+		return updateChecklist(args[0], func(item *models.BacklogItem) (string, error) {
+			item.Checklist = append(item.Checklist, models.ChecklistEntry{Text: text})
+			return fmt.Sprintf("Added checklist entry %d to", len(item.Checklist)), nil
+		})
+	},
+}
+
+var checkToggleCmd = &cobra.Command{
+	Use:   "toggle [id] [entry]",
+	Short: "Mark a checklist entry done, or not done again",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateChecklist(args[0], func(item *models.BacklogItem) (string, error) {
+			n, err := checklistEntry(item, args[1])
+			if err != nil {
+				return "", err
+			}
+			item.Checklist[n].Done = !item.Checklist[n].Done
+			if item.Checklist[n].Done {
+				return fmt.Sprintf("Checked entry %d of", n+1), nil
+			}
+			return fmt.Sprintf("Unchecked entry %d of", n+1), nil
+		})
+	},
+}
+
+var checkRemoveCmd = &cobra.Command{
+	Use:   "remove [id] [entry]",
+	Short: "Remove a checklist entry",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateChecklist(args[0], func(item *models.BacklogItem) (string, error) {
+			n, err := checklistEntry(item, args[1])
+			if err != nil {
+				return "", err
+			}
+			item.Checklist = append(item.Checklist[:n], item.Checklist[n+1:]...)
+			return fmt.Sprintf("Removed entry %d from", n+1), nil
+		})
+	},
+}
+
+func init() {
+	checkCmd.AddCommand(checkAddCmd)
+	checkCmd.AddCommand(checkToggleCmd)
+	checkCmd.AddCommand(checkRemoveCmd)
+}
+
+// updateChecklist applies change to the checklist of the item with the
+// given ID and saves it. change returns the start of the success message,
+// which is completed with the item's title and progress.
+func updateChecklist(id string, change func(item *models.BacklogItem) (string, error)) error {
+	// Create storage
+	store, unlock, err := openLockedStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Load backlog
+	backlog, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	// Find item
+	i, err := models.Resolve(backlog.Items, id)
+	if err != nil {
+		return err
+	}
+	item := &backlog.Items[i]
+
+	// Work on a copy so the loaded checklist isn't changed in place
+	item.Checklist = append([]models.ChecklistEntry(nil), item.Checklist...)
+	message, err := change(item)
+	if err != nil {
+		return err
+	}
+	item.UpdatedAt = time.Now()
+
+	// Save
+	if err := store.Save(backlog); err != nil {
+		return err
+	}
+
+	progress := models.ChecklistSummary(item.Checklist)
+	if progress == "" {
+		progress = "empty"
+	}
+	fmt.Printf("✓ %s %s (%s)\n", message, item.Title, progress)
+	return nil
+}
+
+// checklistEntry resolves a 1-based checklist entry number to an index
+func checklistEntry(item *models.BacklogItem, arg string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+	if err != nil || n < 1 || n > len(item.Checklist) {
+		return -1, fmt.Errorf("%s has no checklist entry %s (it has %d)", item.Ref(), arg, len(item.Checklist))
+	}
+	return n - 1, nil
+}
+
+// formatChecklistEntry renders a checklist entry as "1. [x] text"
+func formatChecklistEntry(n int, entry models.ChecklistEntry) string {
+	box := "[ ]"
+	if entry.Done {
+		box = "[x]"
+	}
+	return fmt.Sprintf("%d. %s %s", n+1, box, entry.Text)
+}
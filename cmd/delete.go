@@ -2,26 +2,26 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/vvb/backlog/models"
-	"github.com/vvb/backlog/storage"
 )
 
 var deleteCmd = &cobra.Command{
-	Use:   "delete [id]",
-	Short: "Delete a backlog item",
-	Long:  `Delete a backlog item by its ID.`,
-	Args:  cobra.ExactArgs(1),
+	Use:         "delete [id]",
+	Short:       "Delete a backlog item",
+	Long:        `Delete a backlog item by its ID. Deleted items go to the trash and can be restored with 'backlog trash restore'.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: printsItems,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		id := args[0]
 
 		// Create storage
-		store, err := storage.New()
+		store, unlock, err := openLockedStore()
 		if err != nil {
 			return err
 		}
+		defer unlock()
 
 		// Load backlog
 		backlog, err := store.Load()
@@ -29,32 +29,26 @@ var deleteCmd = &cobra.Command{
 			return err
 		}
 
-		// Find and remove item
-		found := false
-		newItems := []models.BacklogItem{}
-		var deletedTitle string
-
-		for _, item := range backlog.Items {
-			if strings.HasPrefix(item.ID, id) {
-				found = true
-				deletedTitle = item.Title
-			} else {
-				newItems = append(newItems, item)
-			}
-		}
-
-		if !found {
-			return fmt.Errorf("item with ID %s not found", id)
+		// Find the item, refusing ambiguous prefixes
+		i, err := models.Resolve(backlog.Items, id)
+		if err != nil {
+			return err
 		}
 
-		backlog.Items = newItems
-
-		// Save
-		if err := store.Save(backlog); err != nil {
+		// Move it to the trash
+		timed := backlog.Items[i].TimerStarted != nil
+		item, err := trashItem(store, backlog, backlog.Items[i].ID)
+		if err != nil {
 			return err
 		}
 
-		fmt.Printf("✓ Deleted backlog item: %s\n", deletedTitle)
+		if structuredOutput() {
+			return printItem(*item)
+		}
+		if timed {
+			fmt.Printf("✓ Stopped timer on %s after %s\n", item.Title, formatDuration(item.TimeLog[len(item.TimeLog)-1].Duration()))
+		}
+		fmt.Printf("✓ Moved backlog item to the trash: %s\n", item.Title)
 		return nil
 	},
 }
@@ -0,0 +1,400 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/models"
+	"gopkg.in/yaml.v3"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit [id]",
+	Short: "Edit a backlog item in your editor",
+	Long: `Open a backlog item in $VISUAL or $EDITOR (vi if neither is set) as a
+Markdown file: its title, status, priority, due date, tags, parent epic and
+recurrence rule as YAML front matter, and its description below. The item is
+updated when the editor exits, after checking the fields the same way update
+does. Save an empty file to cancel.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: printsItems,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workflow, err := boardWorkflow()
+		if err != nil {
+			return err
+		}
+
+		// Create storage
+		store, err := openStoreForUpdate()
+		if err != nil {
+			return err
+		}
+
+		// Load backlog
+		backlog, err := store.Load()
+		if err != nil {
+			return err
+		}
+
+		// Find item
+		i, err := models.Resolve(backlog.Items, args[0])
+		if err != nil {
+			return err
+		}
+		item := backlog.Items[i]
+
+		// The store isn't locked while the editor is open, which may take
+		// a while; the item is checked for changes made meanwhile below
+		path, err := writeItemFile(backlog.Items, workflow, item)
+		if err != nil {
+			return err
+		}
+		editor := editorCommand(path)
+		editor.Stdin, editor.Stdout, editor.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := editor.Run(); err != nil {
+			os.Remove(path)
+			return fmt.Errorf("failed to run editor: %w", err)
+		}
+
+		// Lock the board only now, for the reload and the save
+		unlock, err := store.Lock()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		// Load backlog again, with any changes made while editing
+		if backlog, err = store.Load(); err != nil {
+			return err
+		}
+		i, err = models.Resolve(backlog.Items, item.ID)
+		if err != nil {
+			return fmt.Errorf("%w (your edit is kept in %s)", err, path)
+		}
+		if !backlog.Items[i].UpdatedAt.Equal(item.UpdatedAt) {
+			return fmt.Errorf("item %s was changed while you were editing it (your edit is kept in %s)", item.Ref(), path)
+		}
+
+		warnings, next, err := applyItemFile(backlog, workflow, i, path, time.Now())
+		if errors.Is(err, errEditUnchanged) {
+			fmt.Printf("No changes to backlog item: %s\n", item.Title)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Save
+		if err := store.Save(backlog); err != nil {
+			return fmt.Errorf("%w (your edit is kept in %s)", err, path)
+		}
+		os.Remove(path)
+
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "⚠ %s\n", warning)
+		}
+
+		if structuredOutput() {
+			if next >= 0 {
+				fmt.Fprintf(os.Stderr, "✓ Created next instance: %s (%s), due %s\n", backlog.Items[next].Title, backlog.Items[next].Ref(), backlog.Items[next].DueDate)
+			}
+			return printItem(backlog.Items[i])
+		}
+
+		fmt.Printf("✓ Updated backlog item: %s\n", backlog.Items[i].Title)
+		if next >= 0 {
+			fmt.Printf("✓ Created next instance: %s (%s), due %s\n", backlog.Items[next].Title, backlog.Items[next].Ref(), backlog.Items[next].DueDate)
+		}
+		return nil
+	},
+}
+
+// errEditUnchanged is returned by applyItemFile when the file was saved
+// unchanged or empty, leaving the item as it was
+var errEditUnchanged = errors.New("item not changed")
+
+// itemDocument is the YAML front matter of an item opened in an editor.
+// Its description is the Markdown body below it.
+type itemDocument struct {
+	Title    string   `yaml:"title"`
+	Status   string   `yaml:"status"`
+	Priority string   `yaml:"priority"`
+	Due      string   `yaml:"due"`
+	Tags     []string `yaml:"tags,flow"`
+	Parent   string   `yaml:"parent"`
+	Repeat   string   `yaml:"repeat"`
+}
+
+// newItemDocument returns the front matter of item. The parent epic is
+// given by its number, or its ID if it's no longer on the board.
+func newItemDocument(items []models.BacklogItem, item models.BacklogItem) itemDocument {
+	doc := itemDocument{
+		Title:    item.Title,
+		Status:   string(item.Status),
+		Priority: string(item.Priority),
+		Due:      item.DueDate,
+		Tags:     append([]string{}, item.Tags...),
+		Parent:   item.Parent,
+		Repeat:   item.Repeat,
+	}
+	for _, parent := range items {
+		if parent.ID == item.Parent {
+			doc.Parent = parent.Ref()
+		}
+	}
+	return doc
+}
+
+// formatItemDocument renders item as a Markdown file with front matter,
+// with comments listing the values the fields take
+func formatItemDocument(items []models.BacklogItem, workflow *models.Workflow, item models.BacklogItem) (string, error) {
+	front, err := yaml.Marshal(newItemDocument(items, item))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode item: %w", err)
+	}
+	priorities := make([]string, len(models.Priorities))
+	for i, p := range models.Priorities {
+		priorities[i] = string(p)
+	}
+	statuses := make([]models.Status, len(workflow.Statuses))
+	for i, def := range workflow.Statuses {
+		statuses[i] = def.Status
+	}
+
+	var s strings.Builder
+	s.WriteString("---\n")
+	fmt.Fprintf(&s, "# %s (%s). Save an empty file to cancel.\n", item.Ref(), item.ID)
+	fmt.Fprintf(&s, "# status: %s\n", models.JoinStatuses(statuses))
+	fmt.Fprintf(&s, "# priority: %s, or empty; due: DD-MM-YYYY, or empty\n", strings.Join(priorities, ", "))
+	s.WriteString("# parent: the epic's number or ID, or empty; repeat: daily, weekly, weekly:mon,thu, monthly, every:3d, or empty\n")
+	s.Write(front)
+	s.WriteString("---\n\n")
+	if item.Description != "" {
+		s.WriteString(item.Description + "\n")
+	}
+	return s.String(), nil
+}
+
+// parseItemDocument splits a file written by formatItemDocument, and
+// edited since, into its front matter and description. Fields left out of
+// the front matter keep their values in doc.
+func parseItemDocument(text string, doc itemDocument) (itemDocument, string, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+
+	rest, ok := strings.CutPrefix(text, "---\n")
+	if !ok {
+		return doc, "", fmt.Errorf("the file must start with a --- line, followed by the item's fields")
+	}
+	front, body := "", ""
+	if after, ok := strings.CutPrefix(rest, "---\n"); ok {
+		body = after
+	} else if front, body, ok = strings.Cut(rest, "\n---\n"); !ok {
+		return doc, "", fmt.Errorf("missing --- line after the item's fields")
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(front), &node); err != nil {
+		return doc, "", fmt.Errorf("invalid fields: %w", err)
+	}
+	if len(node.Content) == 0 {
+		return doc, strings.TrimSpace(body), nil
+	}
+
+	// Unknown fields are refused so a misspelt one isn't silently lost
+	fields := node.Content[0]
+	if fields.Kind != yaml.MappingNode {
+		return doc, "", fmt.Errorf("invalid fields: expected one field per line, like title: ...")
+	}
+	names := itemDocumentFields()
+	for k := 0; k < len(fields.Content); k += 2 {
+		key := fields.Content[k]
+		if !slices.Contains(names, key.Value) {
+			return doc, "", fmt.Errorf("unknown field %q on line %d. Use: %s", key.Value, key.Line, strings.Join(names, ", "))
+		}
+	}
+	if err := fields.Decode(&doc); err != nil {
+		return doc, "", fmt.Errorf("invalid fields: %w", err)
+	}
+	return doc, strings.TrimSpace(body), nil
+}
+
+// itemDocumentFields returns the names of the fields of an itemDocument
+func itemDocumentFields() []string {
+	var names []string
+	t := reflect.TypeOf(itemDocument{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		names = append(names, name)
+	}
+	return names
+}
+
+// applyItemDocument validates an edited document and applies it to the
+// item at index i. Fields are checked the way update checks its flags, and
+// nothing is changed unless all of them are valid. It returns warnings to
+// show and the index of the next instance of a recurring item that was
+// completed, or -1.
+func applyItemDocument(backlog *models.Backlog, workflow *models.Workflow, i int, doc itemDocument, description string, now time.Time) ([]string, int, error) {
+	item := backlog.Items[i]
+	before := newItemDocument(backlog.Items, item)
+
+	title := strings.TrimSpace(doc.Title)
+	if title == "" {
+		return nil, -1, fmt.Errorf("title is required")
+	}
+	priority, err := models.ParsePriority(doc.Priority)
+	if err != nil {
+		return nil, -1, err
+	}
+	due := strings.TrimSpace(doc.Due)
+	if due != "" && !isValidDateFormat(due) {
+		return nil, -1, fmt.Errorf("invalid due date %q. Use DD-MM-YYYY", due)
+	}
+	repeat, err := parseRepeat(strings.TrimSpace(doc.Repeat))
+	if err != nil {
+		return nil, -1, err
+	}
+	var tags []string
+	for _, tag := range doc.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	// Moves are checked like update --status
+	var warnings []string
+	status := models.Status(strings.TrimSpace(doc.Status))
+	moved := status != item.Status
+	if moved {
+		if err := workflow.CheckStatus(status); err != nil {
+			return nil, -1, err
+		}
+		if err := workflow.CheckMove(item.Status, status); err != nil {
+			return nil, -1, err
+		}
+		warning, err := checkWIPLimit(workflow, backlog.Items, status)
+		if err != nil {
+			return nil, -1, err
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+		if warning := blockedWarning(backlog.Items, workflow, item, status); warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+
+	// The parent is only looked up when it was changed, so an epic that
+	// has since been archived doesn't get in the way
+	parent := item.Parent
+	if ref := strings.TrimSpace(doc.Parent); ref != before.Parent {
+		parent = ""
+		if ref != "" && ref != "none" {
+			j, err := models.Resolve(backlog.Items, ref)
+			if err != nil {
+				return nil, -1, fmt.Errorf("parent: %w", err)
+			}
+			if err := models.CheckParent(backlog.Items, item, backlog.Items[j]); err != nil {
+				return nil, -1, err
+			}
+			parent = backlog.Items[j].ID
+		}
+	}
+
+	// Update fields
+	item.Title = title
+	item.Description = description
+	if moved {
+		item.Rank = models.RankLast(backlog.Items, status, item.ID)
+	}
+	item.Status = status
+	item.Priority = priority
+	item.DueDate = due
+	item.Tags = tags
+	item.Parent = parent
+	item.Repeat = repeat
+	item.UpdatedAt = now
+	backlog.Items[i] = item
+
+	// Completing the item stops its timer and schedules the next
+	// instance of a recurring item
+	next := -1
+	if moved && status == workflow.Done {
+		if _, next, err = completeItem(backlog, workflow, i, now); err != nil {
+			return nil, -1, err
+		}
+	}
+	return warnings, next, nil
+}
+
+// writeItemFile writes item to a temporary Markdown file for editing and
+// returns its path
+func writeItemFile(items []models.BacklogItem, workflow *models.Workflow, item models.BacklogItem) (string, error) {
+	text, err := formatItemDocument(items, workflow, item)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp("", "backlog-*.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to create file to edit: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write file to edit: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// applyItemFile reads the file written by writeItemFile back and applies
+// it to the item at index i with applyItemDocument. It returns
+// errEditUnchanged if the file is empty or wasn't changed. The file is kept
+// when there's an error, and the error names it so the edit isn't lost.
+func applyItemFile(backlog *models.Backlog, workflow *models.Workflow, i int, path string, now time.Time) ([]string, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, -1, fmt.Errorf("failed to read edited file: %w", err)
+	}
+	original, err := formatItemDocument(backlog.Items, workflow, backlog.Items[i])
+	if err != nil {
+		return nil, -1, err
+	}
+	if strings.TrimSpace(string(data)) == "" || string(data) == original {
+		os.Remove(path)
+		return nil, -1, errEditUnchanged
+	}
+
+	doc, description, err := parseItemDocument(string(data), newItemDocument(backlog.Items, backlog.Items[i]))
+	if err != nil {
+		return nil, -1, fmt.Errorf("%w (your edit is kept in %s)", err, path)
+	}
+	warnings, next, err := applyItemDocument(backlog, workflow, i, doc, description, now)
+	if err != nil {
+		return nil, -1, fmt.Errorf("%w (your edit is kept in %s)", err, path)
+	}
+	return warnings, next, nil
+}
+
+// editorCommand returns the command opening path in the user's editor:
+// $VISUAL, $EDITOR or vi. The variables may include arguments, as in
+// "code --wait".
+func editorCommand(path string) *exec.Cmd {
+	editor := strings.Fields(os.Getenv("VISUAL"))
+	if len(editor) == 0 {
+		editor = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	return exec.Command(editor[0], append(editor[1:], path)...)
+}
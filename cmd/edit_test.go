@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vvb/backlog/models"
+)
+
+// editBacklog returns a board with an epic, #1, and the item to edit, #2
+func editBacklog() *models.Backlog {
+	return &models.Backlog{Items: []models.BacklogItem{
+		{ID: "e", Number: 1, Title: "Epic", Status: models.StatusTodo},
+		{ID: "a", Number: 2, Title: "Fix login", Description: "Steps", Status: models.StatusTodo, Tags: []string{"api"}},
+	}}
+}
+
+func TestApplyItemFile(t *testing.T) {
+	workflow := models.DefaultWorkflow()
+	original, err := formatItemDocument(editBacklog().Items, workflow, editBacklog().Items[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		edit func(text string) string
+		err  string // "" when the edit applies
+	}{
+		{"unchanged", func(text string) string { return text }, errEditUnchanged.Error()},
+		{"emptied", func(text string) string { return "\n" }, errEditUnchanged.Error()},
+		{"no opening ---", func(text string) string { return strings.TrimPrefix(text, "---\n") }, "must start with a --- line"},
+		{"garbled opening ---", func(text string) string { return "-- -" + strings.TrimPrefix(text, "---") }, "must start with a --- line"},
+		{"no closing ---", func(text string) string { return strings.Replace(text, "---\n\n", "\n", 1) }, "missing --- line"},
+		{"invalid yaml", func(text string) string { return strings.Replace(text, "tags: [api]", "tags: [api", 1) }, "invalid fields"},
+		{"not a mapping", func(text string) string { return "---\n- title\n---\n" }, "one field per line"},
+		{"unknown field", func(text string) string { return strings.Replace(text, "title:", "colour: red\ntitle:", 1) }, `unknown field "colour"`},
+		{"no title", func(text string) string { return strings.Replace(text, "title: Fix login", "title: ''", 1) }, "title is required"},
+		{"invalid status", func(text string) string { return strings.Replace(text, "\nstatus: todo\n", "\nstatus: doing\n", 1) }, "doing"},
+		{"invalid priority", func(text string) string { return strings.Replace(text, `priority: ""`, "priority: P9", 1) }, "P9"},
+		{"invalid due date", func(text string) string { return strings.Replace(text, `due: ""`, "due: 31-02-2026", 1) }, "invalid due date"},
+		{"unknown parent", func(text string) string { return strings.Replace(text, `parent: ""`, `parent: "#99"`, 1) }, "parent:"},
+		{"own parent", func(text string) string { return strings.Replace(text, `parent: ""`, `parent: "#2"`, 1) }, "can't be its own parent"},
+		{"invalid repeat", func(text string) string { return strings.Replace(text, `repeat: ""`, "repeat: yearly", 1) }, "invalid repeat rule"},
+		{"applied", func(text string) string {
+			text = strings.Replace(text, "title: Fix login", "title: Fix the login page", 1)
+			text = strings.Replace(text, "\nstatus: todo\n", "\nstatus: in-progress\n", 1)
+			text = strings.Replace(text, `parent: ""`, `parent: "#1"`, 1)
+			return strings.Replace(text, "Steps", "New steps", 1)
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "item.md")
+			if err := os.WriteFile(path, []byte(tt.edit(original)), 0644); err != nil {
+				t.Fatal(err)
+			}
+			backlog := editBacklog()
+
+			_, _, err := applyItemFile(backlog, workflow, 1, path, time.Now())
+
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				item := backlog.Items[1]
+				if item.Title != "Fix the login page" || item.Status != models.StatusInProgress || item.Parent != "e" || item.Description != "New steps" {
+					t.Errorf("edited item = %+v", item)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("err = %v, want it to mention %q", err, tt.err)
+			}
+			if !reflect.DeepEqual(backlog, editBacklog()) {
+				t.Errorf("a refused edit changed the board: %+v", backlog.Items)
+			}
+			_, statErr := os.Stat(path)
+			if errors.Is(err, errEditUnchanged) {
+				if statErr == nil {
+					t.Error("the unchanged file wasn't removed")
+				}
+				return
+			}
+			if statErr != nil {
+				t.Errorf("the refused edit wasn't kept: %v", statErr)
+			}
+			if !strings.Contains(err.Error(), path) {
+				t.Errorf("err = %v, want it to name the kept file", err)
+			}
+		})
+	}
+}
+
+func TestParseItemDocumentKeepsMissingFields(t *testing.T) {
+	doc := itemDocument{Title: "Fix login", Status: "todo", Tags: []string{"api"}}
+
+	got, description, err := parseItemDocument("---\r\ntitle: Renamed\r\n---\r\n\r\nBody\r\n", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := itemDocument{Title: "Renamed", Status: "todo", Tags: []string{"api"}}
+	if !reflect.DeepEqual(got, want) || description != "Body" {
+		t.Errorf("got %+v and %q, want %+v and \"Body\"", got, description, want)
+	}
+
+	// Empty front matter changes nothing
+	if got, _, err = parseItemDocument("---\n---\n", doc); err != nil || !reflect.DeepEqual(got, doc) {
+		t.Errorf("empty front matter: got %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/models"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history [id]",
+	Short: "Show the change history of an item",
+	Long:  `Show every recorded change to an item, including status transitions, with old and new values.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id := args[0]
+
+		// Create storage
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+
+		// Look in the backlog first, then in the archive
+		backlog, err := store.Load()
+		if err != nil {
+			return err
+		}
+		archive, err := store.LoadArchive()
+		if err != nil {
+			return err
+		}
+
+		var found *models.BacklogItem
+		for _, items := range [][]models.BacklogItem{backlog.Items, archive.Items} {
+			i, err := models.Resolve(items, id)
+			if errors.Is(err, models.ErrItemNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			found = &items[i]
+			break
+		}
+
+		if found == nil {
+			return fmt.Errorf("item with ID %s not found", id)
+		}
+
+		fmt.Printf("\nHistory of '%s' (ID: %s)\n\n", found.Title, found.Ref())
+		if len(found.History) == 0 {
+			fmt.Printf("No changes recorded (created %s)\n\n", found.CreatedAt.Format("02-01-2006 15:04"))
+			return nil
+		}
+		for _, change := range found.History {
+			fmt.Println(formatChange(change))
+		}
+		fmt.Println()
+
+		return nil
+	},
+}
+
+// formatChange renders one history entry as a single line
+func formatChange(change models.Change) string {
+	return fmt.Sprintf("%s  %-12s %s → %s",
+		change.At.Format("02-01-2006 15:04"), change.Field, historyValue(change.Old), historyValue(change.New))
+}
+
+// historyValue shortens long values such as descriptions to keep one
+// change per line
+func historyValue(v string) string {
+	if v == "" {
+		return "(none)"
+	}
+	if runes := []rune(v); len(runes) > 40 {
+		return string(runes[:37]) + "..."
+	}
+	return v
+}
@@ -1,19 +1,25 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/vvb/backlog/filter"
+	"github.com/vvb/backlog/journal"
 	"github.com/vvb/backlog/models"
 	"github.com/vvb/backlog/storage"
 )
 
 type reloadMsg struct {
 	backlog *models.Backlog
+	archive *models.Backlog
+	message string
 	err     error
 }
 
@@ -27,7 +33,30 @@ type updateItemMsg struct {
 	err  error
 }
 
+// editorMsg reports that the editor opened on an item's file has exited
+type editorMsg struct {
+	id   string
+	path string
+	err  error
+}
+
+// editItemMsg reports the result of saving an item edited in the editor
+type editItemMsg struct {
+	item      models.BacklogItem
+	next      *models.BacklogItem // the next instance of a recurring item
+	warnings  []string
+	unchanged bool
+	err       error
+}
+
 type moveItemMsg struct {
+	item    models.BacklogItem
+	next    *models.BacklogItem // the next instance of a recurring item
+	stopped *models.TimeEntry   // the time logged by stopping its timer
+	err     error
+}
+
+type rankItemMsg struct {
 	item models.BacklogItem
 	err  error
 }
@@ -37,12 +66,38 @@ type deleteItemMsg struct {
 	err       error
 }
 
+type archiveMsg struct {
+	archive *models.Backlog
+	err     error
+}
+
+type unarchiveMsg struct {
+	item models.BacklogItem
+	err  error
+}
+
+// timerTickMsg refreshes the running timer shown in the status ribbon
+type timerTickMsg struct{}
+
+func timerTick() tea.Cmd {
+	return tea.Tick(time.Minute, func(time.Time) tea.Msg { return timerTickMsg{} })
+}
+
+type undoMsg struct {
+	summary string
+	backlog *models.Backlog
+	archive *models.Backlog
+	err     error
+}
+
 type model struct {
 	backlog        *models.Backlog
-	storage        *storage.Storage
+	storage        storage.Store
 	cursor         int
-	selectedCol    int // 0=todo, 1=in-progress, 2=done
-	items          [3][]models.BacklogItem
+	selectedCol    int // index into columns
+	workflow       *models.Workflow
+	columns        []models.StatusDef // workflow statuses plus any other in use
+	items          [][]models.BacklogItem
 	err            error
 	message        string
 	showHelp       bool
@@ -53,9 +108,22 @@ type model struct {
 	viewingItem    *models.BacklogItem
 	editInputs     []textinput.Model
 	editFocus      int
+	noteMode       bool // typing a new note in the detail view
+	noteInput      textinput.Model
 	searchMode     bool
 	searchInput    textinput.Model
 	searchQuery    string
+	searchFilter   filter.Expr // parsed searchQuery
+	searchErr      string      // why the query in the search box didn't parse
+	epicFilter     string      // ID of the epic whose items the board is limited to
+	views          []storage.View
+	activeView     string // name of the view picked in the view menu
+	sortOrder      string // order of the items in each column, see models.SortItemsBy
+	viewMenu       bool
+	viewCursor     int // 0 is the unfiltered board, then the views
+	archiveMode    bool
+	archive        *models.Backlog
+	archiveCursor  int
 	terminalWidth  int
 	terminalHeight int
 }
@@ -104,31 +172,57 @@ var (
 	statusTabInactiveStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("#7D56F4")).
 				Padding(0, 2)
+
+	statusTabOverLimitStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#FF0000")).
+				Bold(true).
+				Padding(0, 2)
+
+	statusTabTimerStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#04B575")).
+				Bold(true).
+				Padding(0, 2)
 )
 
 const (
 	tagIcon = "\U0001F3F7 " // label/tag
 	dueIcon = "\U000023F0 " // alarm clock
+
+	checklistIcon = "\U00002611 " // ballot box with check
+	epicIcon      = "\U0001F4E6 " // package
+	blockedIcon   = "\U000026D4 " // no entry
+	repeatIcon    = "\U0001F501 " // repeat
 )
 
-func initialModel(backlog *models.Backlog, store *storage.Storage) model {
+// maxDetailHistory is how many history entries the detail view shows
+const maxDetailHistory = 8
+
+// maxDetailNotes is how many notes the detail view shows
+const maxDetailNotes = 5
+
+func initialModel(backlog, archive *models.Backlog, store storage.Store, workflow *models.Workflow) model {
 	m := model{
-		backlog: backlog,
-		storage: store,
-		cursor:  0,
-		// Start in the IN PROGRESS column by default for more relevant view
-		selectedCol:    1,
+		backlog:        backlog,
+		archive:        archive,
+		storage:        store,
+		cursor:         0,
+		workflow:       workflow,
 		showHelp:       true,
 		addMode:        false,
-		inputs:         make([]textinput.Model, 4),
-		editInputs:     make([]textinput.Model, 4),
+		inputs:         make([]textinput.Model, 5),
+		editInputs:     make([]textinput.Model, 5),
 		terminalWidth:  120, // Default, will be updated by Init
 		terminalHeight: 30,  // Default, will be updated by Init
 	}
 	m.organizeItems()
+	// Start in the IN PROGRESS column by default for more relevant view
+	if i := workflow.Index(models.StatusInProgress); i >= 0 {
+		m.selectedCol = i
+	}
 	m.initInputs()
 	m.initEditInputs()
 	m.initSearchInput()
+	m.initNoteInput()
 	return m
 }
 
@@ -153,6 +247,9 @@ func (m *model) initInputs() {
 			t.CharLimit = 10
 		case 3:
 			t.Placeholder = "Tags (comma-separated)"
+		case 4:
+			t.Placeholder = "Priority (P0-P3)"
+			t.CharLimit = 4
 		}
 
 		m.inputs[i] = t
@@ -179,6 +276,9 @@ func (m *model) initEditInputs() {
 			t.CharLimit = 10
 		case 3:
 			t.Placeholder = "Tags (comma-separated)"
+		case 4:
+			t.Placeholder = "Priority (P0-P3)"
+			t.CharLimit = 4
 		}
 
 		m.editInputs[i] = t
@@ -188,58 +288,88 @@ func (m *model) initEditInputs() {
 func (m *model) initSearchInput() {
 	m.searchInput = textinput.New()
 	m.searchInput.Placeholder = "Search items..."
-	m.searchInput.CharLimit = 100
+	m.searchInput.CharLimit = 200
 	m.searchInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF"))
 	m.searchInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
 	m.searchInput.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FAFAFA"))
 }
 
+func (m *model) initNoteInput() {
+	m.noteInput = textinput.New()
+	m.noteInput.Placeholder = "What happened?"
+	m.noteInput.CharLimit = 1000
+	m.noteInput.Width = 80
+	m.noteInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF"))
+	m.noteInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	m.noteInput.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FAFAFA"))
+}
+
 func (m *model) organizeItems() {
-	m.items[0] = []models.BacklogItem{} // todo
-	m.items[1] = []models.BacklogItem{} // in-progress
-	m.items[2] = []models.BacklogItem{} // done
+	m.columns = m.workflow.Columns(m.backlog.Items)
+	m.items = make([][]models.BacklogItem, len(m.columns))
+	if m.selectedCol >= len(m.columns) {
+		m.selectedCol = 0
+		m.cursor = 0
+	}
+
+	// Drop the epic filter once the epic has left the board
+	if m.epic() == nil {
+		m.epicFilter = ""
+	}
+	var inEpic map[string]bool
+	if m.epicFilter != "" {
+		inEpic = models.Descendants(m.backlog.Items, m.epicFilter)
+	}
 
 	for _, item := range m.backlog.Items {
 		// Filter by search query if active
-		if m.searchQuery != "" {
-			if !m.matchesSearch(item) {
-				continue
-			}
+		if m.searchFilter != nil && !m.searchFilter.Match(item) {
+			continue
 		}
 
-		switch item.Status {
-		case models.StatusTodo:
-			m.items[0] = append(m.items[0], item)
-		case models.StatusInProgress:
-			m.items[1] = append(m.items[1], item)
-		case models.StatusDone:
-			m.items[2] = append(m.items[2], item)
+		// Only show the epic's items while filtering by one
+		if inEpic != nil && !inEpic[item.ID] {
+			continue
+		}
+
+		for c, column := range m.columns {
+			if item.Status == column.Status {
+				m.items[c] = append(m.items[c], item)
+				break
+			}
 		}
 	}
-}
 
-func (m model) matchesSearch(item models.BacklogItem) bool {
-	query := m.searchQuery
-	// Search in title, description, and tags
-	if strings.Contains(strings.ToLower(item.Title), query) {
-		return true
+	// Most urgent first
+	for i := range m.items {
+		models.SortItemsBy(m.items[i], m.sortOrder)
 	}
-	if strings.Contains(strings.ToLower(item.Description), query) {
-		return true
+}
+
+// epic returns the epic the board is filtered by, if any
+func (m model) epic() *models.BacklogItem {
+	if m.epicFilter == "" {
+		return nil
 	}
-	for _, tag := range item.Tags {
-		if strings.Contains(strings.ToLower(tag), query) {
-			return true
+	for i := range m.backlog.Items {
+		if m.backlog.Items[i].ID == m.epicFilter {
+			return &m.backlog.Items[i]
 		}
 	}
-	return false
+	return nil
 }
 
 func (m model) Init() tea.Cmd {
-	return tea.WindowSize()
+	return tea.Batch(tea.WindowSize(), timerTick())
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// Keep the timer ticking whatever mode we're in; rendering again is
+	// all it takes to update the ribbon
+	if _, ok := msg.(timerTickMsg); ok {
+		return m, timerTick()
+	}
+
 	// Handle add mode separately for key messages only. Non-key
 	// messages (like addItemMsg) should still be processed by the
 	// general handler below so that the list is refreshed after a
@@ -260,11 +390,25 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	// Handle archive mode separately for key messages only, like view mode
+	if m.archiveMode {
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return m.updateArchiveMode(msg)
+		}
+	}
+
 	// Handle search mode separately
 	if m.searchMode {
 		return m.updateSearchMode(msg)
 	}
 
+	// Handle the view menu separately for key messages only
+	if m.viewMenu {
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return m.updateViewMenu(msg)
+		}
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.terminalWidth = msg.Width
@@ -285,6 +429,20 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.searchInput.Focus()
 			return m, nil
 
+		case "v":
+			if len(m.views) == 0 {
+				m.message = "No saved views (save one with 'backlog view save <name> <query>')"
+				return m, nil
+			}
+			m.viewMenu = true
+			m.viewCursor = 0
+			for i, view := range m.views {
+				if view.Name == m.activeView {
+					m.viewCursor = i + 1
+				}
+			}
+			return m, nil
+
 		case "a":
 			m.addMode = true
 			m.focusIndex = 0
@@ -301,38 +459,58 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.editInputs[1].SetValue(m.viewingItem.Description)
 				m.editInputs[2].SetValue(m.viewingItem.DueDate)
 				m.editInputs[3].SetValue(strings.Join(m.viewingItem.Tags, ", "))
+				m.editInputs[4].SetValue(string(m.viewingItem.Priority))
 				m.editFocus = 0
 				m.editInputs[0].Focus()
 			}
 			return m, nil
 
+		case "e":
+			// Edit the selected item in $EDITOR, suspending the board
+			// until the editor exits
+			column := m.items[m.selectedCol]
+			if len(column) == 0 {
+				return m, nil
+			}
+			item := column[m.cursor]
+			path, err := writeItemFile(m.backlog.Items, m.workflow, item)
+			if err != nil {
+				m.message = fmt.Sprintf("ERROR: %v", err)
+				return m, nil
+			}
+			return m, tea.ExecProcess(editorCommand(path), func(err error) tea.Msg {
+				return editorMsg{id: item.ID, path: path, err: err}
+			})
+
 		case "t":
-			// View TODO items in the main panel
+			// View the first column (TODO by default) in the main panel
 			m.selectedCol = 0
 			m.cursor = 0
 			return m, nil
 
 		case "i":
-			// View IN PROGRESS items in the main panel
-			m.selectedCol = 1
-			m.cursor = 0
+			// View IN PROGRESS items in the main panel, if the workflow has it
+			if i := m.workflow.Index(models.StatusInProgress); i >= 0 {
+				m.selectedCol = i
+				m.cursor = 0
+			}
 			return m, nil
 
 		case "c":
 			// View DONE (completed) items in the main panel
-			m.selectedCol = 2
+			m.selectedCol = m.workflow.Index(m.workflow.Done)
 			m.cursor = 0
 			return m, nil
 
 		case "tab":
 			// Cycle status view forward via the bottom ribbon tabs
-			m.selectedCol = (m.selectedCol + 1) % 3
+			m.selectedCol = (m.selectedCol + 1) % len(m.columns)
 			m.cursor = 0
 			return m, nil
 
 		case "shift+tab":
 			// Cycle status view backward
-			m.selectedCol = (m.selectedCol + 2) % 3
+			m.selectedCol = (m.selectedCol + len(m.columns) - 1) % len(m.columns)
 			m.cursor = 0
 			return m, nil
 
@@ -343,7 +521,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case "right":
-			if m.selectedCol < 2 {
+			if m.selectedCol < len(m.columns)-1 {
 				m.selectedCol++
 				m.cursor = 0
 			}
@@ -359,20 +537,77 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor++
 			}
 
-		case "1":
-			return m, m.moveItemToStatus(models.StatusTodo)
-
-		case "2":
-			return m, m.moveItemToStatus(models.StatusInProgress)
-
-		case "3":
-			return m, m.moveItemToStatus(models.StatusDone)
+		case "K", "shift+up", "J", "shift+down":
+			if m.searchQuery != "" {
+				m.message = "Clear the search to reorder items"
+				return m, nil
+			}
+			if m.epicFilter != "" {
+				m.message = "Press E to show the whole board before reordering items"
+				return m, nil
+			}
+			if m.sortOrder != "" && m.sortOrder != "rank" {
+				m.message = fmt.Sprintf("Items are sorted by %s; switch back to the whole board to reorder them", m.sortOrder)
+				return m, nil
+			}
+			delta := 1
+			if key := msg.String(); key == "K" || key == "shift+up" {
+				delta = -1
+			}
+			return m, m.rankCurrentItem(delta)
+
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			// Move the selected item to the column with that number
+			c := int(msg.String()[0] - '1')
+			column := m.items[m.selectedCol]
+			if c >= len(m.workflow.Statuses) || len(column) == 0 {
+				return m, nil
+			}
+			status := m.workflow.Statuses[c].Status
+			if column[m.cursor].Status == status {
+				return m, nil
+			}
+			if err := m.workflow.CheckMove(column[m.cursor].Status, status); err != nil {
+				m.message = fmt.Sprintf("ERROR: %v", err)
+				return m, nil
+			}
+			if _, err := checkWIPLimit(m.workflow, m.backlog.Items, status); err != nil {
+				m.message = fmt.Sprintf("ERROR: %v", err)
+				return m, nil
+			}
+			return m, m.moveItemToStatus(status)
 
 		case "d":
 			return m, m.deleteCurrentItem()
 
 		case "r":
 			return m, m.reloadData()
+
+		case "A":
+			return m, m.loadArchive()
+
+		case "E":
+			// Limit the board to the selected epic's items, or show
+			// everything again
+			if m.epicFilter != "" {
+				m.epicFilter = ""
+			} else if column := m.items[m.selectedCol]; len(column) > 0 {
+				item := column[m.cursor]
+				if len(models.Children(m.backlog.Items, item.ID)) == 0 {
+					m.message = fmt.Sprintf("'%s' has no child items", item.Title)
+					return m, nil
+				}
+				m.epicFilter = item.ID
+			}
+			m.organizeItems()
+			m.cursor = 0
+			return m, nil
+
+		case "u":
+			return m, m.undoLastChange(false)
+
+		case "ctrl+r":
+			return m, m.undoLastChange(true)
 		}
 
 	case reloadMsg:
@@ -380,12 +615,19 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.err = msg.err
 		} else {
 			m.backlog = msg.backlog
+			m.archive = msg.archive
 			m.organizeItems()
 			m.cursor = 0
 			m.message = "Reloaded data"
+			if msg.message != "" {
+				m.message = msg.message
+			}
 		}
 
 	case addItemMsg:
+		if errors.Is(msg.err, storage.ErrConflict) {
+			return m, m.reloadAfterConflict()
+		}
 		if msg.err != nil {
 			m.err = msg.err
 		} else {
@@ -402,6 +644,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case updateItemMsg:
+		if errors.Is(msg.err, storage.ErrConflict) {
+			return m, m.reloadAfterConflict()
+		}
 		if msg.err != nil {
 			m.err = msg.err
 			m.message = fmt.Sprintf("ERROR: %v", msg.err)
@@ -420,7 +665,37 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.viewingItem = nil
 		}
 
+	case editorMsg:
+		return m, m.saveEditorItem(msg)
+
+	case editItemMsg:
+		if errors.Is(msg.err, storage.ErrConflict) {
+			return m, m.reloadAfterConflict()
+		}
+		if msg.err != nil {
+			m.message = fmt.Sprintf("ERROR: %v", msg.err)
+		} else if msg.unchanged {
+			m.message = fmt.Sprintf("No changes to '%s'", msg.item.Title)
+		} else {
+			// Keep the cursor on the item if it's still in this column
+			m.organizeItems()
+			m.cursor = 0
+			if i := indexByID(m.items[m.selectedCol], msg.item.ID); i >= 0 {
+				m.cursor = i
+			}
+			m.message = fmt.Sprintf("Updated '%s'", msg.item.Title)
+			if msg.next != nil {
+				m.message += fmt.Sprintf("; next instance %s is due %s", msg.next.Ref(), msg.next.DueDate)
+			}
+			for _, warning := range msg.warnings {
+				m.message += " (" + warning + ")"
+			}
+		}
+
 	case moveItemMsg:
+		if errors.Is(msg.err, storage.ErrConflict) {
+			return m, m.reloadAfterConflict()
+		}
 		if msg.err != nil {
 			m.err = msg.err
 			m.message = fmt.Sprintf("ERROR: %v", msg.err)
@@ -428,13 +703,84 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.organizeItems()
 			m.cursor = 0
 			if msg.item.Title != "" {
-				m.message = fmt.Sprintf("Moved '%s' to %s", msg.item.Title, string(msg.item.Status))
+				m.message = fmt.Sprintf("Moved '%s' to %s", msg.item.Title, m.workflow.Label(msg.item.Status))
+				if msg.stopped != nil {
+					m.message += fmt.Sprintf("; stopped its timer after %s", formatDuration(msg.stopped.Duration()))
+				}
+				if msg.next != nil {
+					m.message += fmt.Sprintf("; next instance %s is due %s", msg.next.Ref(), msg.next.DueDate)
+				}
+				limit := m.workflow.Limit(msg.item.Status)
+				if count := models.CountStatuses(m.backlog.Items)[msg.item.Status]; limit > 0 && count > limit {
+					m.message += fmt.Sprintf(", which is over its WIP limit (%d/%d)", count, limit)
+				}
+				if warning := blockedWarning(m.backlog.Items, m.workflow, msg.item, msg.item.Status); warning != "" {
+					m.message += " (" + warning + ")"
+				}
 			} else {
 				m.message = "Moved item"
 			}
 		}
 
+	case rankItemMsg:
+		if errors.Is(msg.err, storage.ErrConflict) {
+			return m, m.reloadAfterConflict()
+		}
+		if msg.err != nil {
+			m.err = msg.err
+			m.message = fmt.Sprintf("ERROR: %v", msg.err)
+		} else if msg.item.ID != "" {
+			// Keep the cursor on the moved item
+			m.organizeItems()
+			if i := indexByID(m.items[m.selectedCol], msg.item.ID); i >= 0 {
+				m.cursor = i
+			}
+			m.message = fmt.Sprintf("Moved '%s'", msg.item.Title)
+		}
+
+	case archiveMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("ERROR: %v", msg.err)
+		} else {
+			m.archive = msg.archive
+			m.archiveMode = true
+			if m.archiveCursor >= len(m.archivedItems()) {
+				m.archiveCursor = 0
+			}
+		}
+
+	case unarchiveMsg:
+		if errors.Is(msg.err, storage.ErrConflict) {
+			m.archiveMode = false
+			return m, m.reloadAfterConflict()
+		}
+		if msg.err != nil {
+			m.message = fmt.Sprintf("ERROR: %v", msg.err)
+		} else {
+			m.organizeItems()
+			if m.archiveCursor >= len(m.archivedItems()) && m.archiveCursor > 0 {
+				m.archiveCursor--
+			}
+			m.message = fmt.Sprintf("Unarchived '%s'", msg.item.Title)
+		}
+
+	case undoMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("ERROR: %v", msg.err)
+		} else {
+			m.backlog = msg.backlog
+			m.archive = msg.archive
+			m.organizeItems()
+			if m.cursor >= len(m.items[m.selectedCol]) {
+				m.cursor = 0
+			}
+			m.message = msg.summary
+		}
+
 	case deleteItemMsg:
+		if errors.Is(msg.err, storage.ErrConflict) {
+			return m, m.reloadAfterConflict()
+		}
 		if msg.err != nil {
 			m.err = msg.err
 			m.message = fmt.Sprintf("ERROR: %v", msg.err)
@@ -444,7 +790,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor--
 			}
 			if msg.itemTitle != "" {
-				m.message = fmt.Sprintf("Deleted '%s'", msg.itemTitle)
+				m.message = fmt.Sprintf("Moved '%s' to the trash", msg.itemTitle)
 			} else {
 				m.message = "Deleted item"
 			}
@@ -543,14 +889,31 @@ func (m model) View() string {
 		return m.renderSearchMode()
 	}
 
+	// Show the view menu
+	if m.viewMenu {
+		return m.renderViewMenu()
+	}
+
+	// Show the archive instead of the board
+	if m.archiveMode && m.archive != nil {
+		return m.renderArchiveView()
+	}
+
 	var s strings.Builder
 	var headerBuilder strings.Builder
 
 	// Title
 	title := "BACKLOG KANBAN BOARD"
-	if m.searchQuery != "" {
+	switch {
+	case m.activeView != "":
+		title += fmt.Sprintf(" (view: %s)", m.activeView)
+	case m.searchQuery != "":
 		title += fmt.Sprintf(" (filtered: '%s')", m.searchQuery)
 	}
+	title += sortSuffix(m.sortOrder)
+	if epic := m.epic(); epic != nil {
+		title += fmt.Sprintf(" (epic: %s %s)", epic.Ref(), epic.Title)
+	}
 	headerBuilder.WriteString(titleStyle.Render(title) + "\n\n")
 
 	header := headerBuilder.String()
@@ -565,15 +928,7 @@ func (m model) View() string {
 	}
 
 	// Determine the title based on the currently selected column
-	currentTitle := "TODO"
-	switch m.selectedCol {
-	case 0:
-		currentTitle = "TODO"
-	case 1:
-		currentTitle = "IN PROGRESS"
-	case 2:
-		currentTitle = "DONE"
-	}
+	currentTitle := strings.ToUpper(m.columns[m.selectedCol].Label())
 
 	// Choose a height for the main panel so that message + stats + help + ribbon sit near the bottom
 	panelHeight := 0
@@ -609,13 +964,16 @@ func (m model) View() string {
 
 	// Stats
 	total := len(m.backlog.Items)
-	s.WriteString(fmt.Sprintf("Total: %d items (%d todo, %d in-progress, %d done)\n\n",
-		total, len(m.items[0]), len(m.items[1]), len(m.items[2])))
+	counts := make([]string, len(m.columns))
+	for c, column := range m.columns {
+		counts[c] = fmt.Sprintf("%d %s", len(m.items[c]), column.Status)
+	}
+	s.WriteString(fmt.Sprintf("Total: %d items (%s)\n\n", total, strings.Join(counts, ", ")))
 
 	// Help legend split across two lines to avoid overflowing
 	if m.showHelp {
-		viewsNav := "Views: t=todo i=in-progress c=done (or tab/shift+tab/left/right) | Navigation: up/down items"
-		actions := "Actions: Enter=edit s=search a=add 1=todo 2-in-progress 3=done d=delete r=reload | ?=help q=quit"
+		viewsNav := "Views: t=first i=in-progress c=done (or tab/shift+tab/left/right) | Navigation: up/down items, K/J move item up/down"
+		actions := fmt.Sprintf("Actions: Enter=edit e=$EDITOR s=search v=views a=add %s d=delete u=undo ctrl+r=redo r=reload A=archive E=epic filter | ?=help q=quit", m.moveKeysHelp())
 		s.WriteString(helpStyle.Render(viewsNav) + "\n")
 		s.WriteString(helpStyle.Render(actions))
 	} else {
@@ -667,18 +1025,54 @@ func (m model) renderColumnWithSize(title string, colIndex int, width int, heigh
 	return style.Render(content.String())
 }
 
+// moveKeysHelp describes the number keys that move the selected item,
+// spelling out the statuses while the workflow is short enough
+func (m model) moveKeysHelp() string {
+	statuses := m.workflow.Statuses
+	if len(statuses) > 9 {
+		statuses = statuses[:9]
+	}
+	if len(statuses) > 4 {
+		return fmt.Sprintf("1-%d=move to column", len(statuses))
+	}
+	keys := make([]string, len(statuses))
+	for i, def := range statuses {
+		keys[i] = fmt.Sprintf("%d=%s", i+1, def.Status)
+	}
+	return strings.Join(keys, " ")
+}
+
 func (m model) renderStatusRibbon() string {
-	labels := []string{"Todo", "In-Progress", "Done"}
 	var tabs []string
 
-	for i, label := range labels {
+	// Columns with a WIP limit show how full they are, like "3/4"
+	counts := models.CountStatuses(m.backlog.Items)
+	for i, column := range m.columns {
+		label := column.Label()
 		style := statusTabInactiveStyle
+		if column.Limit > 0 {
+			label += fmt.Sprintf(" %d/%d", counts[column.Status], column.Limit)
+			if counts[column.Status] > column.Limit {
+				style = statusTabOverLimitStyle
+			}
+		}
 		if i == m.selectedCol {
 			style = statusTabActiveStyle
 		}
 		tabs = append(tabs, style.Render(label))
 	}
 
+	// The running timer, if any, is shown after the columns
+	if i := models.RunningTimer(m.backlog.Items); i >= 0 {
+		item := m.backlog.Items[i]
+		title := item.Title
+		if len(title) > 20 {
+			title = title[:17] + "..."
+		}
+		label := fmt.Sprintf("⏱ %s %s %s", item.Ref(), title, formatDuration(time.Since(*item.TimerStarted)))
+		tabs = append(tabs, statusTabTimerStyle.Render(label))
+	}
+
 	ribbon := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
 	width := m.terminalWidth
 	if width <= 0 {
@@ -698,13 +1092,37 @@ func (m model) formatItemWithWidth(item models.BacklogItem, width int) string {
 	// Calculate available width for title
 	titleWidth := width - 20 // Reserve space for tags and date
 
-	// Title
+	// Number and title
 	title := item.Title
-	if len(title) > titleWidth {
+	if item.Number > 0 {
+		title = item.Ref() + " " + title
+	}
+	if item.Priority != "" {
+		titleWidth -= len(item.Priority) + 3
+	}
+	if titleWidth > 3 && len(title) > titleWidth {
 		title = title[:titleWidth-3] + "..."
 	}
+	if item.Priority != "" {
+		title = priorityBadge(item.Priority) + " " + title
+	}
 	parts = append(parts, title)
 
+	// Blocked by items that aren't done yet
+	if len(models.OpenBlockers(m.backlog.Items, item, m.workflow.Done)) > 0 {
+		parts = append(parts, blockedIcon+"blocked")
+	}
+
+	// Checklist progress
+	if progress := models.ChecklistSummary(item.Checklist); progress != "" {
+		parts = append(parts, checklistIcon+progress)
+	}
+
+	// Epic progress
+	if done, total := m.childProgress(item.ID); total > 0 {
+		parts = append(parts, fmt.Sprintf("%s%d/%d done", epicIcon, done, total))
+	}
+
 	// Tags
 	if len(item.Tags) > 0 {
 		tags := strings.Join(item.Tags, ",")
@@ -715,14 +1133,35 @@ func (m model) formatItemWithWidth(item models.BacklogItem, width int) string {
 		parts = append(parts, tagIcon+tags)
 	}
 
-	// Due date
+	// Due date and recurrence rule
 	if item.DueDate != "" {
 		parts = append(parts, dueIcon+item.DueDate)
 	}
+	if item.Repeat != "" {
+		parts = append(parts, repeatIcon+item.Repeat)
+	}
 
 	return strings.Join(parts, " | ")
 }
 
+// priorityColors are the badge backgrounds, from most to least urgent
+var priorityColors = map[models.Priority]string{
+	models.PriorityP0: "#FF0000",
+	models.PriorityP1: "#FFA500",
+	models.PriorityP2: "#00BFFF",
+	models.PriorityP3: "#626262",
+}
+
+// priorityBadge renders a priority as a colored badge
+func priorityBadge(p models.Priority) string {
+	return lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color(priorityColors[p])).
+		Padding(0, 1).
+		Render(string(p))
+}
+
 func (m model) renderSearchMode() string {
 	searchStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
@@ -735,6 +1174,10 @@ func (m model) renderSearchMode() string {
 	s.WriteString(titleStyle.Render("SEARCH") + "\n\n")
 	s.WriteString("Enter search query:\n\n")
 	s.WriteString(m.searchInput.View() + "\n\n")
+	if m.searchErr != "" {
+		s.WriteString(errorStyle.Render(m.searchErr) + "\n\n")
+	}
+	s.WriteString(helpStyle.Render("e.g. login, \"exact phrase\", status:todo tag:api -tag:wontfix due<2025-12-01 priority>=P1") + "\n")
 	s.WriteString(helpStyle.Render("Enter: search | Esc: cancel") + "\n")
 
 	return searchStyle.Render(s.String())
@@ -750,10 +1193,20 @@ func (m *model) moveItemToStatus(status models.Status) tea.Cmd {
 
 		// Update in backlog
 		var updated models.BacklogItem
+		var stopped *models.TimeEntry
+		next := -1
 		for i := range m.backlog.Items {
 			if m.backlog.Items[i].ID == item.ID {
+				now := time.Now()
+				m.backlog.Items[i].Rank = models.RankLast(m.backlog.Items, status, item.ID)
 				m.backlog.Items[i].Status = status
-				m.backlog.Items[i].UpdatedAt = time.Now()
+				m.backlog.Items[i].UpdatedAt = now
+				if status == m.workflow.Done {
+					var err error
+					if stopped, next, err = completeItem(m.backlog, m.workflow, i, now); err != nil {
+						return moveItemMsg{err: err}
+					}
+				}
 				updated = m.backlog.Items[i]
 				break
 			}
@@ -763,6 +1216,11 @@ func (m *model) moveItemToStatus(status models.Status) tea.Cmd {
 		if err := m.storage.Save(m.backlog); err != nil {
 			return moveItemMsg{err: err}
 		}
+		if next >= 0 {
+			// Saving numbered the new instance
+			created := m.backlog.Items[next]
+			return moveItemMsg{item: updated, next: &created, stopped: stopped}
+		}
 
 		// If we didn't find the item for some reason, still send a message to
 		// trigger a re-organize and status update based on our best guess.
@@ -771,7 +1229,73 @@ func (m *model) moveItemToStatus(status models.Status) tea.Cmd {
 			updated.Status = status
 		}
 
-		return moveItemMsg{item: updated, err: nil}
+		return moveItemMsg{item: updated, stopped: stopped, err: nil}
+	}
+}
+
+// saveEditorItem applies the file the editor was opened on to the item,
+// the same way the edit command does
+func (m *model) saveEditorItem(msg editorMsg) tea.Cmd {
+	return func() tea.Msg {
+		if msg.err != nil {
+			os.Remove(msg.path)
+			return editItemMsg{err: fmt.Errorf("failed to run editor: %w", msg.err)}
+		}
+
+		i := -1
+		for j := range m.backlog.Items {
+			if m.backlog.Items[j].ID == msg.id {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			return editItemMsg{err: fmt.Errorf("item with ID %s not found (your edit is kept in %s)", msg.id, msg.path)}
+		}
+
+		warnings, next, err := applyItemFile(m.backlog, m.workflow, i, msg.path, time.Now())
+		if errors.Is(err, errEditUnchanged) {
+			return editItemMsg{item: m.backlog.Items[i], unchanged: true}
+		}
+		if err != nil {
+			return editItemMsg{err: err}
+		}
+
+		// Save
+		if err := m.storage.Save(m.backlog); err != nil {
+			return editItemMsg{err: fmt.Errorf("%w (your edit is kept in %s)", err, msg.path)}
+		}
+		os.Remove(msg.path)
+
+		edited := editItemMsg{item: m.backlog.Items[i], warnings: warnings}
+		if next >= 0 {
+			// Saving numbered the new instance
+			created := m.backlog.Items[next]
+			edited.next = &created
+		}
+		return edited
+	}
+}
+
+// rankCurrentItem moves the selected item up (delta -1) or down (delta 1)
+// within its column, changing only the ranks needed to keep it there
+func (m *model) rankCurrentItem(delta int) tea.Cmd {
+	column := m.items[m.selectedCol]
+	to := m.cursor + delta
+	if len(column) == 0 || to < 0 || to >= len(column) {
+		return nil
+	}
+
+	return func() tea.Msg {
+		changed := models.Reorder(column, m.cursor, to)
+		applyRanks(m.backlog, changed)
+
+		// Save
+		if err := m.storage.Save(m.backlog); err != nil {
+			return rankItemMsg{err: err}
+		}
+
+		return rankItemMsg{item: changed[len(changed)-1]}
 	}
 }
 
@@ -783,17 +1307,14 @@ func (m *model) deleteCurrentItem() tea.Cmd {
 
 		item := m.items[m.selectedCol][m.cursor]
 
-		// Remove from backlog
-		newItems := make([]models.BacklogItem, 0, len(m.backlog.Items))
-		for _, i := range m.backlog.Items {
-			if i.ID != item.ID {
-				newItems = append(newItems, i)
-			}
+		// Move to the trash, the same way the delete command does
+		unlock, err := m.storage.Lock()
+		if err != nil {
+			return deleteItemMsg{err: err}
 		}
-		m.backlog.Items = newItems
+		defer unlock()
 
-		// Save
-		if err := m.storage.Save(m.backlog); err != nil {
+		if _, err := trashItem(m.storage, m.backlog, item.ID); err != nil {
 			return deleteItemMsg{err: err}
 		}
 
@@ -801,16 +1322,213 @@ func (m *model) deleteCurrentItem() tea.Cmd {
 	}
 }
 
+// undoLastChange undoes (or with redo set, redoes) the most recent change
+// recorded in the journal and reloads the board
+func (m model) undoLastChange(redo bool) tea.Cmd {
+	return func() tea.Msg {
+		u, ok := m.storage.(undoer)
+		if !ok {
+			return undoMsg{err: fmt.Errorf("this storage backend doesn't record changes")}
+		}
+
+		var tx *journal.Tx
+		var err error
+		summary := "Undid: "
+		if redo {
+			tx, err = u.Redo()
+			summary = "Redid: "
+		} else {
+			tx, err = u.Undo()
+		}
+		if err != nil {
+			return undoMsg{err: err}
+		}
+
+		backlog, err := m.storage.Load()
+		if err != nil {
+			return undoMsg{err: err}
+		}
+		archive, err := m.storage.LoadArchive()
+		if err != nil {
+			return undoMsg{err: err}
+		}
+
+		return undoMsg{summary: summary + tx.Describe(), backlog: backlog, archive: archive}
+	}
+}
+
 func (m model) reloadData() tea.Cmd {
+	return func() tea.Msg {
+		// Reloading applies the auto-archive policy like opening the board
+		dir, err := currentBoardDir()
+		if err != nil {
+			return reloadMsg{err: err}
+		}
+		if err := autoArchive(m.storage, dir); err != nil {
+			return reloadMsg{err: err}
+		}
+
+		backlog, err := m.storage.Load()
+		if err != nil {
+			return reloadMsg{err: err}
+		}
+		archive, err := m.storage.LoadArchive()
+		return reloadMsg{
+			backlog: backlog,
+			archive: archive,
+			err:     err,
+		}
+	}
+}
+
+// reloadAfterConflict re-reads the backlog after a save was rejected
+// because another process changed it, discarding the unsaved change
+func (m model) reloadAfterConflict() tea.Cmd {
 	return func() tea.Msg {
 		backlog, err := m.storage.Load()
+		if err != nil {
+			return reloadMsg{err: err}
+		}
+		archive, err := m.storage.LoadArchive()
 		return reloadMsg{
 			backlog: backlog,
+			archive: archive,
+			message: "Backlog was changed by another process; reloaded, please try again",
 			err:     err,
 		}
 	}
 }
 
+// loadArchive reads the archived items for the archive view
+func (m model) loadArchive() tea.Cmd {
+	return func() tea.Msg {
+		archive, err := m.storage.LoadArchive()
+		return archiveMsg{archive: archive, err: err}
+	}
+}
+
+// childProgress returns how many of the items below the item with the
+// given ID are done, archived ones included, out of how many there are
+func (m model) childProgress(id string) (completed, total int) {
+	var archived []models.BacklogItem
+	if m.archive != nil {
+		archived = m.archive.Items
+	}
+	return models.ChildProgress(m.backlog.Items, archived, id, m.workflow.Done)
+}
+
+// archivedItems returns the archived items shown in the archive view,
+// filtered by the active search
+func (m model) archivedItems() []models.BacklogItem {
+	if m.archive == nil {
+		return nil
+	}
+	items := []models.BacklogItem{}
+	for _, item := range m.archive.Items {
+		if m.searchFilter == nil || m.searchFilter.Match(item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (m model) updateArchiveMode(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		m.message = ""
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+
+		case "esc", "A":
+			m.archiveMode = false
+			return m, nil
+
+		case "up", "k":
+			if m.archiveCursor > 0 {
+				m.archiveCursor--
+			}
+
+		case "down", "j":
+			if m.archiveCursor < len(m.archivedItems())-1 {
+				m.archiveCursor++
+			}
+
+		case "U":
+			return m, m.unarchiveCurrentItem()
+
+		case "r":
+			return m, m.loadArchive()
+		}
+	}
+
+	return m, nil
+}
+
+// unarchiveCurrentItem moves the selected archived item back to the
+// board, the same way the unarchive command does
+func (m *model) unarchiveCurrentItem() tea.Cmd {
+	return func() tea.Msg {
+		items := m.archivedItems()
+		if len(items) == 0 {
+			return nil
+		}
+		item := items[m.archiveCursor]
+
+		unlock, err := m.storage.Lock()
+		if err != nil {
+			return unarchiveMsg{err: err}
+		}
+		defer unlock()
+
+		if _, err := unarchiveItem(m.storage, m.backlog, m.archive, item.ID); err != nil {
+			return unarchiveMsg{err: err}
+		}
+
+		return unarchiveMsg{item: item}
+	}
+}
+
+func (m model) renderArchiveView() string {
+	var s strings.Builder
+
+	items := m.archivedItems()
+	title := fmt.Sprintf("ARCHIVE (%d items)", len(items))
+	if m.searchQuery != "" {
+		title += fmt.Sprintf(" (filtered: '%s')", m.searchQuery)
+	}
+	s.WriteString(titleStyle.Render(title) + "\n\n")
+
+	panelWidth := m.terminalWidth - 2
+	if panelWidth < 10 {
+		panelWidth = m.terminalWidth
+	}
+
+	var content strings.Builder
+	if len(items) == 0 {
+		content.WriteString(helpStyle.Render("(empty)"))
+	} else {
+		for i, item := range items {
+			itemStr := m.formatItemWithWidth(item, panelWidth-4)
+			if i == m.archiveCursor {
+				itemStr = selectedStyle.Render("> " + itemStr)
+			} else {
+				itemStr = "  " + itemStr
+			}
+			content.WriteString(itemStr + "\n")
+		}
+	}
+	s.WriteString(columnStyle.Width(panelWidth).BorderForeground(lipgloss.Color("#FF00FF")).Render(content.String()))
+	s.WriteString("\n\n")
+
+	if m.message != "" {
+		s.WriteString(messageStyle.Render(m.message) + "\n")
+	}
+	s.WriteString(helpStyle.Render("up/down: navigate | U: unarchive | r: reload | A/Esc: back to board | q: quit"))
+
+	return s.String()
+}
+
 func (m model) renderAddForm() string {
 	var s strings.Builder
 
@@ -822,7 +1540,7 @@ func (m model) renderAddForm() string {
 
 	s.WriteString(titleStyle.Render("ADD NEW ITEM") + "\n\n")
 
-	labels := []string{"Title:", "Description:", "Due Date:", "Tags:"}
+	labels := []string{"Title:", "Description:", "Due Date:", "Tags:", "Priority:"}
 	for i := range m.inputs {
 		s.WriteString(labels[i] + "\n")
 		s.WriteString(m.inputs[i].View() + "\n\n")
@@ -851,15 +1569,15 @@ func (m model) submitNewItem() tea.Cmd {
 			return addItemMsg{err: fmt.Errorf("invalid date format. Use DD-MM-YYYY")}
 		}
 
-		// Parse tags
-		var tags []string
-		if tagsStr != "" {
-			tags = strings.Split(tagsStr, ",")
-			for i := range tags {
-				tags[i] = strings.TrimSpace(tags[i])
-			}
+		// Validate priority if provided
+		priority, err := models.ParsePriority(m.inputs[4].Value())
+		if err != nil {
+			return addItemMsg{err: err}
 		}
 
+		// Parse tags
+		tags := splitTags(tagsStr)
+
 		// Create new item
 		item := models.BacklogItem{
 			ID:          generateID(),
@@ -867,7 +1585,8 @@ func (m model) submitNewItem() tea.Cmd {
 			Description: description,
 			DueDate:     dueDate,
 			Tags:        tags,
-			Status:      models.StatusTodo,
+			Status:      m.workflow.Initial(),
+			Priority:    priority,
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
 		}
@@ -892,6 +1611,10 @@ func (m model) submitNewItem() tea.Cmd {
 }
 
 func (m model) updateViewMode(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.noteMode {
+		return m.updateNoteMode(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		key := msg.String()
@@ -918,10 +1641,13 @@ func (m model) updateViewMode(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.editFocus++
 			}
 
-			if m.editFocus > len(m.editInputs)-1 {
+			// Focus moves through the inputs, the checklist entries and
+			// the notes
+			fields := len(m.editInputs) + len(m.viewingItem.Checklist) + 1
+			if m.editFocus > fields-1 {
 				m.editFocus = 0
 			} else if m.editFocus < 0 {
-				m.editFocus = len(m.editInputs) - 1
+				m.editFocus = fields - 1
 			}
 
 			cmds := make([]tea.Cmd, len(m.editInputs))
@@ -939,6 +1665,26 @@ func (m model) updateViewMode(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			return m, tea.Batch(cmds...)
 
+		case " ", "x":
+			// Toggle the focused checklist entry; it's saved with the rest
+			// of the item
+			if n := m.checklistFocus(); n >= 0 {
+				checklist := append([]models.ChecklistEntry(nil), m.viewingItem.Checklist...)
+				checklist[n].Done = !checklist[n].Done
+				m.viewingItem.Checklist = checklist
+				return m, nil
+			}
+			return m, m.updateEditInputs(msg)
+
+		case "n":
+			// Start a new note, unless a text input has the focus
+			if m.editFocus >= len(m.editInputs) {
+				m.noteMode = true
+				m.noteInput.SetValue("")
+				return m, m.noteInput.Focus()
+			}
+			return m, m.updateEditInputs(msg)
+
 		default:
 			// Handle character input for the focused text input
 			cmd := m.updateEditInputs(msg)
@@ -949,6 +1695,52 @@ func (m model) updateViewMode(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// checklistFocus returns the index of the checklist entry focused in the
+// detail view, or -1 while something else has the focus
+func (m model) checklistFocus() int {
+	if m.viewingItem == nil || m.editFocus < len(m.editInputs) {
+		return -1
+	}
+	if n := m.editFocus - len(m.editInputs); n < len(m.viewingItem.Checklist) {
+		return n
+	}
+	return -1
+}
+
+// notesFocus reports whether the notes have the focus in the detail view
+func (m model) notesFocus() bool {
+	return m.viewingItem != nil && m.editFocus == len(m.editInputs)+len(m.viewingItem.Checklist)
+}
+
+// updateNoteMode handles typing a new note in the detail view. The note is
+// saved with the rest of the item.
+func (m model) updateNoteMode(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+
+		case "esc":
+			m.noteMode = false
+			m.noteInput.Blur()
+			return m, nil
+
+		case "enter":
+			if text := strings.TrimSpace(m.noteInput.Value()); text != "" {
+				notes := append([]models.Note(nil), m.viewingItem.Notes...)
+				m.viewingItem.Notes = append(notes, models.Note{At: time.Now(), Text: text})
+			}
+			m.noteMode = false
+			m.noteInput.Blur()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.noteInput, cmd = m.noteInput.Update(msg)
+	return m, cmd
+}
+
 func (m *model) updateEditInputs(msg tea.Msg) tea.Cmd {
 	cmds := make([]tea.Cmd, len(m.editInputs))
 
@@ -981,21 +1773,22 @@ func (m model) saveEditedItem() tea.Cmd {
 			return updateItemMsg{err: fmt.Errorf("invalid date format. Use DD-MM-YYYY")}
 		}
 
-		// Parse tags
-		var tags []string
-		if tagsStr != "" {
-			tags = strings.Split(tagsStr, ",")
-			for i := range tags {
-				tags[i] = strings.TrimSpace(tags[i])
-			}
+		// Validate priority if provided
+		priority, err := models.ParsePriority(m.editInputs[4].Value())
+		if err != nil {
+			return updateItemMsg{err: err}
 		}
 
+		// Parse tags
+		tags := splitTags(tagsStr)
+
 		// Update item
 		updatedItem := *m.viewingItem
 		updatedItem.Title = title
 		updatedItem.Description = description
 		updatedItem.DueDate = dueDate
 		updatedItem.Tags = tags
+		updatedItem.Priority = priority
 		updatedItem.UpdatedAt = time.Now()
 
 		// Update in backlog
@@ -1015,6 +1808,123 @@ func (m model) saveEditedItem() tea.Cmd {
 	}
 }
 
+// applySearch limits the board to the items matching query (see the filter
+// package), or shows them all again for the empty query
+func (m *model) applySearch(query string) error {
+	expr, err := filter.Parse(query)
+	if err != nil {
+		return err
+	}
+	m.searchQuery = query
+	m.searchFilter = expr
+	if query == "" {
+		m.searchFilter = nil
+	}
+	m.organizeItems()
+	return nil
+}
+
+// updateViewMenu handles the view menu, which switches the board between
+// the unfiltered board and the saved views
+func (m model) updateViewMenu(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		key := msg.String()
+		switch key {
+		case "ctrl+c":
+			return m, tea.Quit
+
+		case "esc", "v", "q":
+			m.viewMenu = false
+			return m, nil
+
+		case "up", "k":
+			if m.viewCursor > 0 {
+				m.viewCursor--
+			}
+			return m, nil
+
+		case "down", "j":
+			if m.viewCursor < len(m.views) {
+				m.viewCursor++
+			}
+			return m, nil
+
+		case "0", "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			n := int(key[0] - '0')
+			if n > len(m.views) {
+				return m, nil
+			}
+			m.viewCursor = n
+			return m.pickView(), nil
+
+		case "enter":
+			return m.pickView(), nil
+		}
+	}
+	return m, nil
+}
+
+// pickView switches the board to the view under the menu cursor
+func (m model) pickView() model {
+	m.viewMenu = false
+	m.cursor = 0
+	if m.viewCursor == 0 {
+		m.activeView = ""
+		m.sortOrder = ""
+		m.searchQuery = ""
+		m.searchFilter = nil
+		m.searchInput.SetValue("")
+		m.organizeItems()
+		m.message = "Showing the whole board"
+		return m
+	}
+
+	view := m.views[m.viewCursor-1]
+	m.activeView = view.Name
+	m.sortOrder = view.Sort
+	if err := m.applySearch(view.Query); err != nil {
+		// Views are checked when saved, but board.json may be edited
+		m.activeView = ""
+		m.message = fmt.Sprintf("ERROR: view %s: %v", view.Name, err)
+		return m
+	}
+	m.searchInput.SetValue(view.Query)
+	m.message = fmt.Sprintf("Showing view %s", view.Name)
+	return m
+}
+
+func (m model) renderViewMenu() string {
+	menuStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(1, 2).
+		Width(70)
+
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("VIEWS") + "\n\n")
+	entries := []string{"0  Whole board"}
+	for i, view := range m.views {
+		// Only the first nine views have a number key
+		key := "   "
+		if i < 9 {
+			key = fmt.Sprintf("%d  ", i+1)
+		}
+		entries = append(entries, fmt.Sprintf("%s%s: %s%s", key, view.Name, view.Query, sortSuffix(view.Sort)))
+	}
+	for i, entry := range entries {
+		if i == m.viewCursor {
+			s.WriteString(selectedStyle.Render("> "+entry) + "\n")
+		} else {
+			s.WriteString("  " + entry + "\n")
+		}
+	}
+	s.WriteString("\n")
+	s.WriteString(helpStyle.Render("up/down + Enter or 0-9: pick | Esc: close") + "\n")
+
+	return menuStyle.Render(s.String())
+}
+
 func (m model) updateSearchMode(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -1023,18 +1933,36 @@ func (m model) updateSearchMode(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 
 		case "esc":
+			// Clearing the search leaves the view too
 			m.searchMode = false
 			m.searchQuery = ""
+			m.searchFilter = nil
+			m.searchErr = ""
+			m.activeView = ""
+			m.sortOrder = ""
 			m.searchInput.SetValue("")
 			m.searchInput.Blur()
 			m.organizeItems()
 			return m, nil
 
 		case "enter":
-			m.searchQuery = strings.ToLower(strings.TrimSpace(m.searchInput.Value()))
+			// Keep the search box open until the query parses
+			query := strings.TrimSpace(m.searchInput.Value())
+			changed := query != m.searchQuery
+			if err := m.applySearch(query); err != nil {
+				m.searchErr = err.Error()
+				return m, nil
+			}
+			m.searchErr = ""
+
+			// A different query replaces the view, order and all
+			if changed && m.activeView != "" {
+				m.activeView = ""
+				m.sortOrder = ""
+				m.organizeItems()
+			}
 			m.searchMode = false
 			m.searchInput.Blur()
-			m.organizeItems()
 			m.cursor = 0
 			return m, nil
 		}
@@ -1079,36 +2007,124 @@ func (m model) renderDetailView() string {
 
 	// ID (read-only)
 	s.WriteString(labelStyle.Render("ID: "))
-	s.WriteString(item.ID + "\n\n")
+	s.WriteString(item.Ref() + "  " + helpStyle.Render(item.ID) + "\n\n")
 
 	// Status (read-only for now)
 	s.WriteString(labelStyle.Render("Status: "))
-	statusColor := "#00FF00"
+	statusColor := "#00BFFF"
 	switch item.Status {
-	case models.StatusTodo:
+	case m.workflow.Initial():
 		statusColor = "#FFA500"
-	case models.StatusInProgress:
-		statusColor = "#00BFFF"
-	case models.StatusDone:
+	case m.workflow.Done:
 		statusColor = "#00FF00"
 	}
 	statusStyle = statusStyle.Foreground(lipgloss.Color(statusColor))
-	s.WriteString(statusStyle.Render(string(item.Status)) + "\n\n")
+	s.WriteString(statusStyle.Render(m.workflow.Label(item.Status)) + "\n\n")
+
+	// Recurrence (read-only, set with 'backlog update --repeat')
+	if item.Repeat != "" {
+		s.WriteString(labelStyle.Render("Repeats: "))
+		s.WriteString(item.Repeat + "\n\n")
+	}
+
+	// Epic (read-only, set with 'backlog update --parent')
+	if item.Parent != "" {
+		s.WriteString(labelStyle.Render("Epic: "))
+		if i := indexByID(m.backlog.Items, item.Parent); i >= 0 {
+			s.WriteString(m.backlog.Items[i].Ref() + " " + m.backlog.Items[i].Title + "\n\n")
+		} else {
+			s.WriteString(helpStyle.Render(item.Parent+" (archived or deleted)") + "\n\n")
+		}
+	}
+	if blockers := models.OpenBlockers(m.backlog.Items, *item, m.workflow.Done); len(blockers) > 0 {
+		s.WriteString(labelStyle.Render("Blocked by:") + "\n")
+		for _, blocker := range blockers {
+			s.WriteString(fmt.Sprintf("  %s %s  [%s]\n", blocker.Ref(), blocker.Title, blocker.Status))
+		}
+		s.WriteString("\n")
+	}
+	if done, total := m.childProgress(item.ID); total > 0 {
+		s.WriteString(labelStyle.Render("Children: "))
+		s.WriteString(fmt.Sprintf("%d/%d done", done, total) + "\n\n")
+	}
 
 	// Editable fields
-	labels := []string{"Title:", "Description:", "Due Date:", "Tags:"}
+	labels := []string{"Title:", "Description:", "Due Date:", "Tags:", "Priority:"}
 	for i := range m.editInputs {
 		s.WriteString(labelStyle.Render(labels[i]) + "\n")
 		s.WriteString(m.editInputs[i].View() + "\n\n")
 	}
 
+	// Checklist, toggled with space once focused
+	if len(item.Checklist) > 0 {
+		s.WriteString(labelStyle.Render(fmt.Sprintf("Checklist (%s):", models.ChecklistSummary(item.Checklist))) + "\n")
+		for n, entry := range item.Checklist {
+			line := formatChecklistEntry(n, entry)
+			if n == m.checklistFocus() {
+				line = selectedStyle.Render("> " + line)
+			} else {
+				line = "  " + line
+			}
+			s.WriteString(line + "\n")
+		}
+		s.WriteString("\n")
+	}
+
+	// Notes, most recent last
+	s.WriteString(labelStyle.Render("Notes:") + "\n")
+	notes := item.Notes
+	if len(notes) > maxDetailNotes {
+		s.WriteString(helpStyle.Render(fmt.Sprintf("(%d earlier notes, see 'backlog note %s')", len(notes)-maxDetailNotes, item.Ref())) + "\n")
+		notes = notes[len(notes)-maxDetailNotes:]
+	}
+	for _, note := range notes {
+		s.WriteString("  " + formatNote(note) + "\n")
+	}
+	switch {
+	case m.noteMode:
+		s.WriteString(m.noteInput.View() + "\n")
+	case m.notesFocus():
+		s.WriteString(selectedStyle.Render("> Press n to add a note") + "\n")
+	default:
+		s.WriteString(helpStyle.Render("  (n adds a note when no field is being edited)") + "\n")
+	}
+	s.WriteString("\n")
+
+	// Time spent
+	if len(item.TimeLog) > 0 || item.TimerStarted != nil {
+		s.WriteString(labelStyle.Render("Time spent: "))
+		s.WriteString(formatDuration(item.TimeSpent()))
+		if item.TimerStarted != nil {
+			s.WriteString(fmt.Sprintf(" (+%s running)", formatDuration(time.Since(*item.TimerStarted))))
+		}
+		s.WriteString("\n")
+	}
+
 	// Timestamps
 	s.WriteString(labelStyle.Render("Created: "))
 	s.WriteString(item.CreatedAt.Format("2006-01-02 15:04:05") + "\n")
 	s.WriteString(labelStyle.Render("Updated: "))
 	s.WriteString(item.UpdatedAt.Format("2006-01-02 15:04:05") + "\n\n")
 
-	s.WriteString(helpStyle.Render("Tab/up/down: navigate | Esc/q: save and exit") + "\n")
+	// History, most recent last
+	if len(item.History) > 0 {
+		s.WriteString(labelStyle.Render("History:") + "\n")
+		history := item.History
+		if len(history) > maxDetailHistory {
+			s.WriteString(helpStyle.Render(fmt.Sprintf("(%d earlier changes, see 'backlog history')", len(history)-maxDetailHistory)) + "\n")
+			history = history[len(history)-maxDetailHistory:]
+		}
+		for _, change := range history {
+			s.WriteString(formatChange(change) + "\n")
+		}
+		s.WriteString("\n")
+	}
+
+	if m.noteMode {
+		s.WriteString(helpStyle.Render("Enter: add note | Esc: cancel") + "\n")
+	} else {
+		s.WriteString(helpStyle.Render("Tab/up/down: navigate | Space: toggle checklist entry | n: add note | Esc/q: save and exit") + "\n")
+	}
 
 	return detailStyle.Render(s.String())
 }
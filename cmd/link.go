@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/models"
+)
+
+var linkCmd = &cobra.Command{
+	Use:   "link [id] blocks|blocked-by [id]",
+	Short: "Record that one item blocks another",
+	Long: `Record that an item can't start until another is done:
+
+  backlog link 12 blocks 15       # 15 waits for 12
+  backlog link 15 blocked-by 12   # the same link
+
+Links that would make items wait on each other are refused.`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var blockerRef, blockedRef string
+		switch strings.ToLower(args[1]) {
+		case "blocks":
+			blockerRef, blockedRef = args[0], args[2]
+		case "blocked-by":
+			blockerRef, blockedRef = args[2], args[0]
+		default:
+			return fmt.Errorf("invalid link %q. Use: blocks or blocked-by", args[1])
+		}
+
+		// Create storage
+		store, unlock, err := openLockedStore()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		// Load backlog
+		backlog, err := store.Load()
+		if err != nil {
+			return err
+		}
+
+		// Find both items
+		i, err := models.Resolve(backlog.Items, blockerRef)
+		if err != nil {
+			return err
+		}
+		j, err := models.Resolve(backlog.Items, blockedRef)
+		if err != nil {
+			return err
+		}
+		blocker, blocked := backlog.Items[i], backlog.Items[j]
+
+		if blocked.IsBlockedBy(blocker.ID) {
+			fmt.Printf("%s already blocks %s\n", blocker.Ref(), blocked.Ref())
+			return nil
+		}
+		if err := models.CheckBlock(backlog.Items, blocker, blocked); err != nil {
+			return err
+		}
+
+		backlog.Items[j].BlockedBy = append(append([]string(nil), blocked.BlockedBy...), blocker.ID)
+		backlog.Items[j].UpdatedAt = time.Now()
+
+		// Save
+		if err := store.Save(backlog); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ %s %s now blocks %s %s\n", blocker.Ref(), blocker.Title, blocked.Ref(), blocked.Title)
+		return nil
+	},
+}
+
+var unlinkCmd = &cobra.Command{
+	Use:   "unlink [id] [id]",
+	Short: "Remove the blocking link between two items",
+	Long:  `Remove the blocking link between two items, whichever way it goes.`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Create storage
+		store, unlock, err := openLockedStore()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		// Load backlog
+		backlog, err := store.Load()
+		if err != nil {
+			return err
+		}
+
+		// Find both items
+		i, err := models.Resolve(backlog.Items, args[0])
+		if err != nil {
+			return err
+		}
+		j, err := models.Resolve(backlog.Items, args[1])
+		if err != nil {
+			return err
+		}
+		a, b := &backlog.Items[i], &backlog.Items[j]
+
+		if !a.IsBlockedBy(b.ID) && !b.IsBlockedBy(a.ID) {
+			return fmt.Errorf("%s and %s aren't linked", a.Ref(), b.Ref())
+		}
+		for _, pair := range [][2]*models.BacklogItem{{a, b}, {b, a}} {
+			item, other := pair[0], pair[1]
+			if item.IsBlockedBy(other.ID) {
+				item.BlockedBy = removeID(item.BlockedBy, other.ID)
+				item.UpdatedAt = time.Now()
+			}
+		}
+
+		// Save
+		if err := store.Save(backlog); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Unlinked %s and %s\n", a.Ref(), b.Ref())
+		return nil
+	},
+}
+
+// removeID returns ids without id, leaving ids itself untouched
+func removeID(ids []string, id string) []string {
+	var kept []string
+	for _, other := range ids {
+		if other != id {
+			kept = append(kept, other)
+		}
+	}
+	return kept
+}
+
+// blockedWarning returns a warning to show when the item is moved to
+// status while items it waits on are still open, or "" if there's nothing
+// to warn about. Moving back to the workflow's first status is always fine.
+func blockedWarning(items []models.BacklogItem, workflow *models.Workflow, item models.BacklogItem, status models.Status) string {
+	if status == workflow.Initial() {
+		return ""
+	}
+	blockers := models.OpenBlockers(items, item, workflow.Done)
+	if len(blockers) == 0 {
+		return ""
+	}
+	refs := make([]string, len(blockers))
+	for i, blocker := range blockers {
+		refs[i] = blocker.Ref()
+	}
+	return fmt.Sprintf("%s is still blocked by %s", item.Ref(), strings.Join(refs, ", "))
+}
@@ -6,100 +6,284 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/filter"
 	"github.com/vvb/backlog/models"
 	"github.com/vvb/backlog/storage"
 )
 
-var interactive bool
+var (
+	interactive         bool
+	listStatus          string
+	listTags            string
+	listFilter          string
+	listView            string
+	listSort            string
+	listIncludeArchived bool
+)
 
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all backlog items in Kanban board view",
-	Long:  `Display all backlog items organized in a Kanban-style board with columns for todo, in-progress, and done.`,
+	Long: `Display all backlog items organized in a Kanban-style board with a column for
+each status of the board's workflow (todo, in-progress and done by default).
+
+--filter limits the board to the items matching a query, and --view to those
+matching a saved view (see 'backlog view'). ` + queryHelp,
+	Annotations: printsItems,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Create storage
-		store, err := storage.New()
+		workflow, err := boardWorkflow()
 		if err != nil {
 			return err
 		}
 
-		// Load backlog
-		backlog, err := store.Load()
+		// A view supplies a query and an order; --filter narrows it down
+		// and --sort overrides the order
+		views, err := boardViews()
+		if err != nil {
+			return err
+		}
+		text, order := listFilter, listSort
+		var view *storage.View
+		if listView != "" {
+			if view, err = findView(views, listView); err != nil {
+				return err
+			}
+			text = viewQuery(view.Query, listFilter)
+			if order == "" {
+				order = view.Sort
+			}
+		}
+		if order != "" {
+			if err := models.CheckSortOrder(order); err != nil {
+				return err
+			}
+		}
+
+		// Create storage. Interactive mode can change the board, so it
+		// applies the auto-archive policy like the commands that do
+		open := openStore
+		if interactive {
+			open = openStoreForUpdate
+		}
+		store, err := open()
 		if err != nil {
 			return err
 		}
 
 		// Interactive mode
 		if interactive {
-			p := tea.NewProgram(initialModel(backlog, store))
+			if structuredOutput() {
+				return fmt.Errorf("--output and --template can't be used in interactive mode")
+			}
+
+			// Load backlog and archive
+			backlog, err := store.Load()
+			if err != nil {
+				return err
+			}
+			archive, err := store.LoadArchive()
+			if err != nil {
+				return err
+			}
+
+			// Start with the board filtered by --view and --filter, as
+			// if they had been picked in the session
+			m := initialModel(backlog, archive, store, workflow)
+			m.views = views
+			if view != nil && listFilter == "" {
+				m.activeView = view.Name
+			}
+			m.sortOrder = order
+			if err := m.applySearch(text); err != nil {
+				return err
+			}
+			m.searchInput.SetValue(text)
+
+			p := tea.NewProgram(m)
 			if _, err := p.Run(); err != nil {
 				return err
 			}
 			return nil
 		}
 
+		// Load matching items, letting the backend do the filtering
+		expr, err := filter.Parse(text)
+		if err != nil {
+			return err
+		}
+		query := storage.Query{Tags: splitTags(listTags)}
+		if listStatus != "" {
+			if err := workflow.CheckStatus(models.Status(listStatus)); err != nil {
+				return err
+			}
+			query.Statuses = []models.Status{models.Status(listStatus)}
+		}
+		query = query.WithFilter(expr)
+		items, err := storage.Find(store, query)
+		if err != nil {
+			return err
+		}
+
+		// Scripts get the items in board order: by column, then as sorted
+		// within each column
+		if structuredOutput() {
+			if listIncludeArchived {
+				archived, err := storage.FindArchived(store, query)
+				if err != nil {
+					return err
+				}
+				return printItems(append(boardOrder(items, workflow, order), archived...))
+			}
+			return printItems(boardOrder(items, workflow, order))
+		}
+
+		// WIP limits and blockers apply to the whole board, not just the
+		// matching items
+		backlog, err := store.Load()
+		if err != nil {
+			return err
+		}
+
 		// Display Kanban board
-		displayKanbanBoard(backlog)
+		displayKanbanBoard(&models.Backlog{Items: items}, workflow, backlog.Items, order)
+
+		// Archived items are listed below the board
+		if listIncludeArchived {
+			archived, err := storage.FindArchived(store, query)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Archived: %d item(s)\n\n", len(archived))
+			if len(archived) > 0 {
+				displayArchivedItems(archived)
+				fmt.Println()
+			}
+		}
 		return nil
 	},
 }
 
 func init() {
 	listCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Interactive mode with keyboard navigation")
+	listCmd.Flags().StringVar(&listStatus, "status", "", "Only show items with this status")
+	listCmd.Flags().StringVar(&listTags, "tag", "", "Only show items with all of these comma-separated tags")
+	listCmd.Flags().StringVar(&listFilter, "filter", "", "Only show items matching this query, e.g. 'tag:api priority>=P1'")
+	listCmd.Flags().StringVar(&listView, "view", "", "Only show items matching this saved view, in its order")
+	listCmd.Flags().StringVar(&listSort, "sort", "", "Order of the items in each column: "+strings.Join(models.SortOrders, ", ")+" (default rank)")
+	listCmd.Flags().BoolVar(&listIncludeArchived, "include-archived", false, "Also list matching archived items")
 }
 
-func displayKanbanBoard(backlog *models.Backlog) {
-	// Organize items by status
-	todoItems := []models.BacklogItem{}
-	inProgressItems := []models.BacklogItem{}
-	doneItems := []models.BacklogItem{}
+// displayKanbanBoard prints the items as a board with a column per status,
+// sorted in order (see models.SortItemsBy). board holds all items on the
+// board, to check WIP limits and blockers against.
+func displayKanbanBoard(backlog *models.Backlog, workflow *models.Workflow, board []models.BacklogItem, order string) {
+	counts := models.CountStatuses(board)
+	blocked := map[string]bool{}
+	for _, item := range backlog.Items {
+		blocked[item.ID] = len(models.OpenBlockers(board, item, workflow.Done)) > 0
+	}
 
+	// Organize items by status, most urgent first unless another order
+	// was asked for
+	models.SortItemsBy(backlog.Items, order)
+	columns := workflow.Columns(backlog.Items)
+	items := make([][]models.BacklogItem, len(columns))
 	for _, item := range backlog.Items {
-		switch item.Status {
-		case models.StatusTodo:
-			todoItems = append(todoItems, item)
-		case models.StatusInProgress:
-			inProgressItems = append(inProgressItems, item)
-		case models.StatusDone:
-			doneItems = append(doneItems, item)
+		for c, column := range columns {
+			if item.Status == column.Status {
+				items[c] = append(items[c], item)
+				break
+			}
 		}
 	}
 
-	// Calculate column width
-	const colWidth = 35
+	// Calculate column width, narrowing columns on boards with more than
+	// three so the board keeps its width
+	colWidth := 35
+	if len(columns) > 3 {
+		colWidth = (3*colWidth - 3*(len(columns)-3)) / len(columns)
+		if colWidth < minColWidth {
+			colWidth = minColWidth
+		}
+	}
+	lineWidth := colWidth*len(columns) + 3*(len(columns)-1)
 
-	// Print header
+	// Print header, with "count/limit" for columns that have a WIP limit
+	// and a "!" for those over it
+	headers := make([]string, len(columns))
+	var overLimit []string
+	for c, column := range columns {
+		header := strings.ToUpper(column.Label())
+		if column.Limit > 0 {
+			header = fmt.Sprintf("%s %d/%d", header, counts[column.Status], column.Limit)
+			if counts[column.Status] > column.Limit {
+				header += " !"
+				overLimit = append(overLimit, fmt.Sprintf("%s (%d/%d)", column.Status, counts[column.Status], column.Limit))
+			}
+		}
+		headers[c] = truncate(header, colWidth)
+	}
 	fmt.Println()
-	fmt.Println(strings.Repeat("=", colWidth*3+4))
-	fmt.Printf("%-*s | %-*s | %-*s\n", colWidth, "TODO", colWidth, "IN PROGRESS", colWidth, "DONE")
-	fmt.Println(strings.Repeat("=", colWidth*3+4))
+	fmt.Println(strings.Repeat("=", lineWidth))
+	fmt.Println(strings.Join(headers, " | "))
+	fmt.Println(strings.Repeat("=", lineWidth))
 
 	// Find max rows needed
-	maxRows := max(len(todoItems), len(inProgressItems), len(doneItems))
+	maxRows := 0
+	for _, column := range items {
+		if len(column) > maxRows {
+			maxRows = len(column)
+		}
+	}
 
 	// Print rows
+	separators := make([]string, len(columns))
+	for c := range separators {
+		separators[c] = strings.Repeat("-", colWidth)
+	}
 	for i := 0; i < maxRows; i++ {
-		todoCell := formatCell(todoItems, i, colWidth)
-		inProgressCell := formatCell(inProgressItems, i, colWidth)
-		doneCell := formatCell(doneItems, i, colWidth)
-
-		fmt.Printf("%s | %s | %s\n", todoCell, inProgressCell, doneCell)
+		cells := make([]string, len(columns))
+		for c := range columns {
+			cells[c] = formatCell(items[c], i, colWidth, blocked)
+		}
+		fmt.Println(strings.Join(cells, " | "))
 
 		// Add separator between items
 		if i < maxRows-1 {
-			fmt.Printf("%s | %s | %s\n",
-				strings.Repeat("-", colWidth),
-				strings.Repeat("-", colWidth),
-				strings.Repeat("-", colWidth))
+			fmt.Println(strings.Join(separators, " | "))
 		}
 	}
 
-	fmt.Println(strings.Repeat("=", colWidth*3+4))
-	fmt.Printf("\nTotal: %d items (%d todo, %d in-progress, %d done)\n\n",
-		len(backlog.Items), len(todoItems), len(inProgressItems), len(doneItems))
+	fmt.Println(strings.Repeat("=", lineWidth))
+	totals := make([]string, len(columns))
+	for c, column := range columns {
+		totals[c] = fmt.Sprintf("%d %s", len(items[c]), column.Status)
+	}
+	fmt.Printf("\nTotal: %d items (%s)\n\n", len(backlog.Items), strings.Join(totals, ", "))
+	if len(overLimit) > 0 {
+		fmt.Printf("⚠ Over WIP limit: %s\n\n", strings.Join(overLimit, ", "))
+	}
+}
+
+// boardOrder returns items in the order the board shows them: column by
+// column, sorted in order within each
+func boardOrder(items []models.BacklogItem, workflow *models.Workflow, order string) []models.BacklogItem {
+	models.SortItemsBy(items, order)
+	var sorted []models.BacklogItem
+	for _, column := range workflow.Columns(items) {
+		for _, item := range items {
+			if item.Status == column.Status {
+				sorted = append(sorted, item)
+			}
+		}
+	}
+	return sorted
 }
 
-func formatCell(items []models.BacklogItem, index int, width int) string {
+// minColWidth is the narrowest column the text board uses
+const minColWidth = 16
+
+func formatCell(items []models.BacklogItem, index int, width int, blocked map[string]bool) string {
 	if index >= len(items) {
 		return strings.Repeat(" ", width)
 	}
@@ -110,7 +294,17 @@ func formatCell(items []models.BacklogItem, index int, width int) string {
 	// Tags: tag1, tag2
 	// Due: DD-MM-YYYY
 
-	line1 := fmt.Sprintf("[%s] %s", truncateID(item.ID), item.Title)
+	title := item.Title
+	if progress := models.ChecklistSummary(item.Checklist); progress != "" {
+		title = fmt.Sprintf("(%s) %s", progress, title)
+	}
+	if blocked[item.ID] {
+		title = "BLOCKED " + title
+	}
+	line1 := fmt.Sprintf("[%s] %s", item.Ref(), title)
+	if item.Priority != "" {
+		line1 = fmt.Sprintf("[%s] %s %s", item.Ref(), item.Priority, title)
+	}
 	line1 = truncate(line1, width)
 
 	lines := []string{line1}
@@ -135,21 +329,3 @@ func truncate(s string, maxLen int) string {
 	}
 	return s[:maxLen-3] + "..."
 }
-
-func truncateID(id string) string {
-	if len(id) <= 6 {
-		return id
-	}
-	return id[:6]
-}
-
-func max(a, b, c int) int {
-	result := a
-	if b > result {
-		result = b
-	}
-	if c > result {
-		result = c
-	}
-	return result
-}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/models"
+)
+
+var noteCmd = &cobra.Command{
+	Use:   "note [id] [text]",
+	Short: "Add a note to an item, or list its notes",
+	Long: `Add a timestamped note to an item's notes log, for progress updates that
+don't belong in the description. Without text, the item's notes are listed.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// List notes
+		if len(args) == 1 {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			backlog, err := store.Load()
+			if err != nil {
+				return err
+			}
+			i, err := models.Resolve(backlog.Items, args[0])
+			if err != nil {
+				return err
+			}
+			item := backlog.Items[i]
+
+			if len(item.Notes) == 0 {
+				fmt.Printf("No notes on %s %s\n", item.Ref(), item.Title)
+				return nil
+			}
+			fmt.Printf("\nNotes on '%s' (ID: %s)\n\n", item.Title, item.Ref())
+			for _, note := range item.Notes {
+				fmt.Println(formatNote(note))
+			}
+			fmt.Println()
+			return nil
+		}
+
+		text := strings.TrimSpace(args[1])
+		if text == "" {
+			return fmt.Errorf("note text is required")
+		}
+
+		// Create storage
+		store, unlock, err := openLockedStore()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		// Load backlog
+		backlog, err := store.Load()
+		if err != nil {
+			return err
+		}
+
+		// Find item
+		i, err := models.Resolve(backlog.Items, args[0])
+		if err != nil {
+			return err
+		}
+		item := &backlog.Items[i]
+
+		now := time.Now()
+		item.Notes = append(append([]models.Note(nil), item.Notes...), models.Note{At: now, Text: text})
+		item.UpdatedAt = now
+
+		// Save
+		if err := store.Save(backlog); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Added note to %s (%d note(s))\n", item.Title, len(item.Notes))
+		return nil
+	},
+}
+
+// formatNote renders a note as "02-01-2006 15:04  text"
+func formatNote(note models.Note) string {
+	return fmt.Sprintf("%s  %s", note.At.Format("02-01-2006 15:04"), note.Text)
+}
@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Flags choosing how commands print their results
+var (
+	outputFormat   string
+	outputFields   string
+	outputTemplate string
+)
+
+// outputFormats lists the values --output accepts. "text" is the output
+// meant for people; the others are for scripts.
+var outputFormats = []string{"text", "json", "yaml", "csv", "tsv", "markdown"}
+
+// defaultTableFields are the columns of csv, tsv and markdown output
+// when --fields isn't given. json and yaml show every field that is set.
+var defaultTableFields = []string{"id", "number", "title", "status", "priority", "due_date", "tags"}
+
+// itemTemplate is --template, parsed by checkOutputFlags
+var itemTemplate *template.Template
+
+// outputAnnotation marks the commands that print items, and so can print
+// them for scripts
+const outputAnnotation = "prints-items"
+
+// printsItems is the annotation of the commands that print items
+var printsItems = map[string]string{outputAnnotation: "true"}
+
+// checkOutputFlags validates --output, --fields and --template before
+// cmd runs, so no command changes the backlog and then fails to report
+// it. Commands that don't print items refuse them rather than ignore them.
+func checkOutputFlags(cmd *cobra.Command) error {
+	if cmd.Annotations[outputAnnotation] == "" && (outputFormat != "text" || outputFields != "" || outputTemplate != "") {
+		return fmt.Errorf("--output, --fields and --template can't be used with '%s'", cmd.CommandPath())
+	}
+
+	known := false
+	for _, format := range outputFormats {
+		known = known || outputFormat == format
+	}
+	if !known {
+		return fmt.Errorf("invalid output format %q. Use: %s", outputFormat, strings.Join(outputFormats, ", "))
+	}
+
+	names := itemFieldNames()
+	for _, field := range splitTags(outputFields) {
+		found := false
+		for _, name := range names {
+			found = found || field == name
+		}
+		if !found {
+			return fmt.Errorf("unknown field %q. Use: %s", field, strings.Join(names, ", "))
+		}
+	}
+
+	if outputTemplate != "" {
+		tmpl, err := template.New("output").Funcs(template.FuncMap{"join": strings.Join}).Parse(outputTemplate)
+		if err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+		itemTemplate = tmpl
+	}
+	return nil
+}
+
+// structuredOutput reports whether results are printed for scripts, with
+// --output or --template, instead of for people
+func structuredOutput() bool {
+	return itemTemplate != nil || outputFormat != "text"
+}
+
+// printItem prints the item a command acted on, as a single object in
+// json and yaml
+func printItem(item models.BacklogItem) error {
+	return writeItems(os.Stdout, []models.BacklogItem{item}, true)
+}
+
+// printItems prints the items a command found or acted on
+func printItems(items []models.BacklogItem) error {
+	return writeItems(os.Stdout, items, false)
+}
+
+func writeItems(w io.Writer, items []models.BacklogItem, single bool) error {
+	// A template is executed once per item
+	if itemTemplate != nil {
+		for _, item := range items {
+			if err := itemTemplate.Execute(w, item); err != nil {
+				return fmt.Errorf("failed to execute template: %w", err)
+			}
+			fmt.Fprintln(w)
+		}
+		return nil
+	}
+
+	fields := splitTags(outputFields)
+	tabular := outputFormat == "csv" || outputFormat == "tsv" || outputFormat == "markdown"
+	if len(fields) == 0 && tabular {
+		fields = defaultTableFields
+	}
+	records := make([]record, len(items))
+	for i, item := range items {
+		r, err := itemRecord(item, fields)
+		if err != nil {
+			return err
+		}
+		records[i] = r
+	}
+
+	var value any = records
+	if single && len(records) == 1 {
+		value = records[0]
+	}
+
+	switch outputFormat {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(value); err != nil {
+			return fmt.Errorf("failed to encode yaml: %w", err)
+		}
+		return enc.Close()
+
+	case "csv", "tsv":
+		cw := csv.NewWriter(w)
+		if outputFormat == "tsv" {
+			cw.Comma = '\t'
+		}
+		cw.Write(fields)
+		for _, r := range records {
+			cw.Write(tableRow(r))
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case "markdown":
+		fmt.Fprintf(w, "| %s |\n", strings.Join(fields, " | "))
+		fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(fields)))
+		for _, r := range records {
+			cells := tableRow(r)
+			for i, cell := range cells {
+				cell = strings.ReplaceAll(cell, "|", `\|`)
+				cells[i] = strings.Join(strings.Fields(cell), " ")
+			}
+			fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid output format %q", outputFormat)
+}
+
+// itemFieldNames returns the JSON names of the fields of an item, in
+// declaration order
+func itemFieldNames() []string {
+	var names []string
+	t := reflect.TypeOf(models.BacklogItem{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// recordField is a named value of a record
+type recordField struct {
+	Name  string
+	Value any
+}
+
+// record is an item as an ordered list of its fields, which keeps the
+// fields in declaration order in json and yaml
+type record []recordField
+
+// itemRecord returns the given fields of item, or every field that is set
+// when fields is empty. Values have the types encoding/json decodes to.
+func itemRecord(item models.BacklogItem, fields []string) (record, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal item: %w", err)
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal item: %w", err)
+	}
+
+	if len(fields) == 0 {
+		for _, name := range itemFieldNames() {
+			if _, ok := values[name]; ok {
+				fields = append(fields, name)
+			}
+		}
+	}
+
+	r := make(record, len(fields))
+	for i, name := range fields {
+		r[i] = recordField{Name: name, Value: values[name]}
+	}
+	return r, nil
+}
+
+func (r record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(field.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (r record) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, field := range r {
+		var value yaml.Node
+		if err := value.Encode(field.Value); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: field.Name}, &value)
+	}
+	return node, nil
+}
+
+// tableRow returns the cells of a csv, tsv or markdown row. Lists of
+// strings are joined with commas; other structured values are given as
+// JSON.
+func tableRow(r record) []string {
+	cells := make([]string, len(r))
+	for i, field := range r {
+		switch value := field.Value.(type) {
+		case nil:
+		case string:
+			cells[i] = value
+		case float64:
+			cells[i] = strconv.FormatFloat(value, 'f', -1, 64)
+		case bool:
+			cells[i] = strconv.FormatBool(value)
+		default:
+			if list, ok := stringList(value); ok {
+				cells[i] = strings.Join(list, ",")
+			} else {
+				data, _ := json.Marshal(value)
+				cells[i] = string(data)
+			}
+		}
+	}
+	return cells
+}
+
+// stringList converts a decoded JSON array of strings
+func stringList(value any) ([]string, bool) {
+	values, ok := value.([]any)
+	if !ok {
+		return nil, false
+	}
+	list := make([]string, len(values))
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		list[i] = s
+	}
+	return list, true
+}
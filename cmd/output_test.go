@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vvb/backlog/models"
+	"gopkg.in/yaml.v3"
+)
+
+// resetOutput puts --output, --fields and --template back to their
+// defaults at the end of the test
+func resetOutput(t *testing.T) {
+	t.Cleanup(func() {
+		outputFormat, outputFields, outputTemplate, itemTemplate = "text", "", "", nil
+	})
+}
+
+// useOutput sets --output, --fields and --template for the rest of the
+// test, as checked for a command that prints items
+func useOutput(t *testing.T, format, fields, tmpl string) {
+	t.Helper()
+	resetOutput(t)
+	outputFormat, outputFields, outputTemplate = format, fields, tmpl
+	if err := checkOutputFlags(listCmd); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func outputItems() []models.BacklogItem {
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	return []models.BacklogItem{
+		{ID: "1", Number: 3, Title: "Fix | login", Status: models.StatusTodo, Priority: models.PriorityP1, DueDate: "01-12-2026", Tags: []string{"api", "web"}, CreatedAt: created, UpdatedAt: created},
+		{ID: "2", Number: 4, Title: "Write docs", Status: models.StatusDone, CreatedAt: created, UpdatedAt: created},
+	}
+}
+
+func TestWriteItemsTables(t *testing.T) {
+	tests := []struct {
+		format, fields string
+		want           string
+	}{
+		{"csv", "", "id,number,title,status,priority,due_date,tags\n" +
+			"1,3,Fix | login,todo,P1,01-12-2026,\"api,web\"\n" +
+			"2,4,Write docs,done,,,\n"},
+		{"tsv", "number,title", "number\ttitle\n3\tFix | login\n4\tWrite docs\n"},
+		{"markdown", "number,title,tags", "| number | title | tags |\n" +
+			"| --- | --- | --- |\n" +
+			"| 3 | Fix \\| login | api,web |\n" +
+			"| 4 | Write docs |  |\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			useOutput(t, tt.format, tt.fields, "")
+			var buf bytes.Buffer
+			if err := writeItems(&buf, outputItems(), false); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteItemsJSON(t *testing.T) {
+	useOutput(t, "json", "", "")
+	var buf bytes.Buffer
+	if err := writeItems(&buf, outputItems(), false); err != nil {
+		t.Fatal(err)
+	}
+	var items []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &items); err != nil {
+		t.Fatalf("invalid json %s: %v", buf.String(), err)
+	}
+	if len(items) != 2 || items[0]["title"] != "Fix | login" || items[0]["priority"] != "P1" || items[1]["status"] != "done" {
+		t.Errorf("items = %v", items)
+	}
+	// Fields that aren't set are left out
+	if _, ok := items[1]["priority"]; ok {
+		t.Errorf("unset priority is in the output: %v", items[1])
+	}
+
+	// A single item is an object, with the fields in --fields order
+	useOutput(t, "json", "title,number", "")
+	buf.Reset()
+	if err := writeItems(&buf, outputItems()[:1], true); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "{\n  \"title\": \"Fix | login\",\n  \"number\": 3\n}\n"; got != want {
+		t.Errorf("single item = %s, want %s", got, want)
+	}
+}
+
+func TestWriteItemsYAML(t *testing.T) {
+	useOutput(t, "yaml", "number,title,tags", "")
+	var buf bytes.Buffer
+	if err := writeItems(&buf, outputItems(), false); err != nil {
+		t.Fatal(err)
+	}
+	want := "- number: 3\n  title: Fix | login\n  tags:\n    - api\n    - web\n" +
+		"- number: 4\n  title: Write docs\n  tags: null\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	var items []map[string]any
+	if err := yaml.Unmarshal(buf.Bytes(), &items); err != nil || len(items) != 2 {
+		t.Errorf("output doesn't read back as two items: %v", err)
+	}
+}
+
+func TestWriteItemsTemplate(t *testing.T) {
+	useOutput(t, "text", "", `{{.Number}} {{.Title}} [{{join .Tags ","}}]`)
+	var buf bytes.Buffer
+	if err := writeItems(&buf, outputItems(), false); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "3 Fix | login [api,web]\n4 Write docs []\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestOutputFlagsRefusedByOtherCommands(t *testing.T) {
+	useMemoryStore(t)
+	resetOutput(t)
+
+	for _, args := range [][]string{
+		{"tree", "-o", "json"},
+		{"history", "1", "--fields", "title"},
+		{"board", "workflow", "--template", "{{.Title}}"},
+	} {
+		outputFormat, outputFields, outputTemplate = "text", "", ""
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "can't be used") {
+			t.Errorf("backlog %v: err = %v, want the output flags refused", args, err)
+		}
+	}
+}
+
+func TestListingsPrintItems(t *testing.T) {
+	useMemoryStore(t)
+	resetOutput(t)
+	run(t, "add", "Water plants", "--repeat", "weekly")
+	t.Cleanup(func() { addRepeat = "" })
+
+	if got := output(t, "recurring", "list", "-o", "csv", "--fields", "title,repeat"); got != "title,repeat\nWater plants,weekly\n" {
+		t.Errorf("recurring list = %q", got)
+	}
+	if got := output(t, "trash", "list", "-o", "json"); got != "[]\n" {
+		t.Errorf("empty trash list = %q, want an empty json list", got)
+	}
+}
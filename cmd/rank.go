@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/models"
+)
+
+var (
+	rankBefore string
+	rankAfter  string
+)
+
+var rankCmd = &cobra.Command{
+	Use:   "rank [id]",
+	Short: "Move a backlog item within its column",
+	Long: `Place a backlog item right before or after another item in the same column.
+Ranked items are listed first, in the order you gave them; the others follow
+by priority and due date.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id := args[0]
+
+		if (rankBefore == "") == (rankAfter == "") {
+			return fmt.Errorf("specify exactly one of --before or --after")
+		}
+		target, after := rankBefore, false
+		if rankAfter != "" {
+			target, after = rankAfter, true
+		}
+
+		// Create storage
+		store, unlock, err := openLockedStore()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		// Load backlog
+		backlog, err := store.Load()
+		if err != nil {
+			return err
+		}
+
+		// Find both items
+		i, err := models.Resolve(backlog.Items, id)
+		if err != nil {
+			return err
+		}
+		j, err := models.Resolve(backlog.Items, target)
+		if err != nil {
+			return err
+		}
+		item, other := backlog.Items[i], backlog.Items[j]
+		if item.ID == other.ID {
+			return fmt.Errorf("cannot rank an item relative to itself")
+		}
+		if item.Status != other.Status {
+			return fmt.Errorf("%s is in %s but %s is in %s, move it there first", item.Ref(), item.Status, other.Ref(), other.Status)
+		}
+
+		// Work out where the item lands once it's taken out of its column
+		column := columnItems(backlog.Items, item.Status)
+		from, to := indexByID(column, item.ID), indexByID(column, other.ID)
+		if from < to {
+			to--
+		}
+		if after {
+			to++
+		}
+
+		applyRanks(backlog, models.Reorder(column, from, to))
+		if err := store.Save(backlog); err != nil {
+			return err
+		}
+
+		position := "before"
+		if after {
+			position = "after"
+		}
+		fmt.Printf("✓ Ranked %s %s %s\n", item.Title, position, other.Title)
+		return nil
+	},
+}
+
+func init() {
+	rankCmd.Flags().StringVar(&rankBefore, "before", "", "ID of the item to place it before")
+	rankCmd.Flags().StringVar(&rankAfter, "after", "", "ID of the item to place it after")
+}
+
+// columnItems returns the items with the given status in display order
+func columnItems(items []models.BacklogItem, status models.Status) []models.BacklogItem {
+	column := []models.BacklogItem{}
+	for _, item := range items {
+		if item.Status == status {
+			column = append(column, item)
+		}
+	}
+	models.SortItems(column)
+	return column
+}
+
+// indexByID returns the index of the item with the given ID, or -1
+func indexByID(items []models.BacklogItem, id string) int {
+	for i := range items {
+		if items[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// applyRanks copies the ranks of changed items, as returned by
+// models.Reorder, into the backlog
+func applyRanks(backlog *models.Backlog, changed []models.BacklogItem) {
+	now := time.Now()
+	for _, item := range changed {
+		if i := indexByID(backlog.Items, item.ID); i >= 0 {
+			backlog.Items[i].Rank = item.Rank
+			backlog.Items[i].UpdatedAt = now
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/models"
+)
+
+var recurringCmd = &cobra.Command{
+	Use:   "recurring",
+	Short: "Manage recurring items",
+	Long: `Manage items that repeat. Give an item a rule with 'recurring set', or with
+--repeat on add or update (daily, weekly, weekly:mon,thu, monthly,
+monthly:15 or every:3d); moving it to done creates the next instance with
+its due date advanced. Stop an item repeating with 'recurring clear' or --repeat none.`,
+}
+
+var recurringListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List recurring items and their rules",
+	Args:        cobra.NoArgs,
+	Annotations: printsItems,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Create storage
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+
+		// Load backlog
+		backlog, err := store.Load()
+		if err != nil {
+			return err
+		}
+
+		var items []models.BacklogItem
+		for _, item := range backlog.Items {
+			if item.Repeat != "" {
+				items = append(items, item)
+			}
+		}
+		models.SortItems(items)
+
+		if structuredOutput() {
+			return printItems(items)
+		}
+		if len(items) == 0 {
+			fmt.Println("No recurring items")
+			return nil
+		}
+
+		fmt.Printf("\n%-6s %-36s %-18s %-12s %s\n", "ID", "TITLE", "REPEATS", "DUE", "STATUS")
+		for _, item := range items {
+			due := item.DueDate
+			if due == "" {
+				due = "-"
+			}
+			fmt.Printf("%-6s %-36s %-18s %-12s %s\n", item.Ref(), truncate(item.Title, 36), item.Repeat, due, item.Status)
+		}
+		fmt.Println()
+		fmt.Println("Change a rule with 'backlog recurring set <id> <rule>', or stop it with 'backlog recurring clear <id>'.")
+		fmt.Println()
+		return nil
+	},
+}
+
+var recurringSetCmd = &cobra.Command{
+	Use:   "set [id] [rule]",
+	Short: "Make an item recurring, or change its rule",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rule, err := parseRepeat(args[1])
+		if err != nil {
+			return err
+		}
+		if rule == "" {
+			return fmt.Errorf("a rule is required, use 'recurring clear' to stop an item repeating")
+		}
+
+		item, err := setRepeat(args[0], rule)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ %s repeats %s\n", item.Title, item.Repeat)
+		return nil
+	},
+}
+
+var recurringClearCmd = &cobra.Command{
+	Use:   "clear [id]",
+	Short: "Stop an item repeating",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		item, err := setRepeat(args[0], "")
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ %s no longer repeats\n", item.Title)
+		return nil
+	},
+}
+
+func init() {
+	recurringCmd.AddCommand(recurringListCmd)
+	recurringCmd.AddCommand(recurringSetCmd)
+	recurringCmd.AddCommand(recurringClearCmd)
+}
+
+// setRepeat gives the item with the given ID or number the rule, which
+// parseRepeat has already checked, and returns the updated item
+func setRepeat(id, rule string) (models.BacklogItem, error) {
+	// Create storage
+	store, unlock, err := openLockedStore()
+	if err != nil {
+		return models.BacklogItem{}, err
+	}
+	defer unlock()
+
+	// Load backlog
+	backlog, err := store.Load()
+	if err != nil {
+		return models.BacklogItem{}, err
+	}
+
+	// Find item
+	i, err := models.Resolve(backlog.Items, id)
+	if err != nil {
+		return models.BacklogItem{}, err
+	}
+	backlog.Items[i].Repeat = rule
+	backlog.Items[i].UpdatedAt = time.Now()
+
+	// Save
+	if err := store.Save(backlog); err != nil {
+		return models.BacklogItem{}, err
+	}
+	return backlog.Items[i], nil
+}
+
+// parseRepeat validates a --repeat flag, returning the rule in its
+// canonical form. An empty string or "none" yields no rule.
+func parseRepeat(s string) (string, error) {
+	if s == "" || s == "none" {
+		return "", nil
+	}
+	r, err := models.ParseRecurrence(s)
+	if err != nil {
+		return "", err
+	}
+	return r.String(), nil
+}
+
+// repeatItem adds the next instance of the recurring item at index i to
+// the backlog, handing the rule over to it so the item can't repeat twice.
+// It returns the index of the new item, or -1 if the item doesn't repeat.
+func repeatItem(backlog *models.Backlog, workflow *models.Workflow, i int, now time.Time) (int, error) {
+	item := &backlog.Items[i]
+	if item.Repeat == "" {
+		return -1, nil
+	}
+
+	next, err := item.NextInstance(workflow.Initial(), now)
+	if err != nil {
+		return -1, err
+	}
+	next.ID = generateID()
+	item.Repeat = ""
+
+	backlog.Items = append(backlog.Items, next)
+	return len(backlog.Items) - 1, nil
+}
@@ -1,13 +1,141 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/journal"
+	"github.com/vvb/backlog/models"
+	"github.com/vvb/backlog/storage"
 )
 
+// boardFlag selects the board for a single invocation
+var boardFlag string
+
 var rootCmd = &cobra.Command{
 	Use:   "backlog",
 	Short: "Backlog - A terminal application for managing backlog items",
-	Long:  `Backlog is a CLI tool for creating and managing backlog items with a Kanban-style board view.`,
+	Long: `Backlog is a CLI tool for creating and managing backlog items with a Kanban-style board view.
+
+Data lives in ~/backlog, or in $BACKLOG_DIR when it is set. Use --board to
+work on a named board instead of the default one.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return checkOutputFlags(cmd)
+	},
+}
+
+// openStore returns the storage backend used by every command. Tests can
+// replace it to run commands against storage.NewMemory().
+var openStore = func() (storage.Store, error) {
+	store, _, err := openJournaledBoard()
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// openStoreForUpdate opens the store for a command that changes the board,
+// applying the board's auto-archive policy first. Commands that only read
+// the board use openStore, so looking at it never writes to it, and undo
+// never reverts an archive in place of the change the user just made.
+func openStoreForUpdate() (storage.Store, error) {
+	store, err := openStore()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := currentBoardDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := autoArchive(store, dir); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// openLockedStore opens the store for a command that changes the board and
+// takes the board's lock. Commands hold it from loading the board until
+// they have saved their change, releasing it with unlock, so concurrent
+// runs can't interleave and overwrite each other's changes.
+func openLockedStore() (store storage.Store, unlock func(), err error) {
+	store, err = openStoreForUpdate()
+	if err != nil {
+		return nil, nil, err
+	}
+	if unlock, err = store.Lock(); err != nil {
+		return nil, nil, err
+	}
+	return store, unlock, nil
+}
+
+// openJournaledBoard opens the current board without applying any
+// policies
+func openJournaledBoard() (*journal.Recorder, string, error) {
+	dir, err := currentBoardDir()
+	if err != nil {
+		return nil, "", err
+	}
+
+	store, err := storage.OpenBoard(dir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Record every change so it can be undone
+	return journal.Wrap(store, dir), dir, nil
+}
+
+// currentBoardDir returns the directory of the board commands operate on
+func currentBoardDir() (string, error) {
+	dataDir, err := storage.DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	board, err := currentBoard(dataDir)
+	if err != nil {
+		return "", err
+	}
+
+	return storage.BoardDir(dataDir, board), nil
+}
+
+// boardWorkflow returns the workflow of the current board
+func boardWorkflow() (*models.Workflow, error) {
+	dir, err := currentBoardDir()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := storage.LoadBoardConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return config.BoardWorkflow(), nil
+}
+
+// currentBoard resolves the board to use: the --board flag, then the
+// default remembered in config, then the built-in default board
+func currentBoard(dataDir string) (string, error) {
+	board := boardFlag
+	if board == "" {
+		config, err := storage.LoadConfig(dataDir)
+		if err != nil {
+			return "", err
+		}
+		board = config.DefaultBoard
+	}
+	if board == "" {
+		board = storage.DefaultBoard
+	}
+
+	if !storage.BoardExists(dataDir, board) {
+		return "", fmt.Errorf("board %q does not exist (create it with 'backlog board create %s')", board, board)
+	}
+
+	return board, nil
 }
 
 // Execute runs the root command
@@ -16,10 +144,35 @@ func Execute() error {
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&boardFlag, "board", "", "Board to use (defaults to the board chosen with 'backlog board switch')")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "text", "Output format of the commands that print items (add, update, edit, delete, list, search, archive, trash list, recurring list): "+strings.Join(outputFormats, ", "))
+	rootCmd.PersistentFlags().StringVar(&outputFields, "fields", "", "Comma-separated item fields to output, e.g. id,title,status (with --output)")
+	rootCmd.PersistentFlags().StringVar(&outputTemplate, "template", "", "Go template to print each item with, e.g. '{{.Number}} {{.Title}}'")
+
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(updateCmd)
+	rootCmd.AddCommand(editCmd)
 	rootCmd.AddCommand(deleteCmd)
 	rootCmd.AddCommand(searchCmd)
 	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(boardCmd)
+	rootCmd.AddCommand(storageCmd)
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(redoCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(trashCmd)
+	rootCmd.AddCommand(unarchiveCmd)
+	rootCmd.AddCommand(rankCmd)
+	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(treeCmd)
+	rootCmd.AddCommand(linkCmd)
+	rootCmd.AddCommand(unlinkCmd)
+	rootCmd.AddCommand(noteCmd)
+	rootCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(stopCmd)
+	rootCmd.AddCommand(logCmd)
+	rootCmd.AddCommand(timesheetCmd)
+	rootCmd.AddCommand(recurringCmd)
+	rootCmd.AddCommand(viewCmd)
 }
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/vvb/backlog/models"
+	"github.com/vvb/backlog/storage"
+)
+
+// useMemoryStore runs commands against an in-memory store and an empty
+// data directory for the rest of the test
+func useMemoryStore(t *testing.T) *storage.MemoryStore {
+	t.Helper()
+	t.Setenv("BACKLOG_DIR", t.TempDir())
+
+	store := storage.NewMemory()
+	previous := openStore
+	openStore = func() (storage.Store, error) { return store, nil }
+	t.Cleanup(func() { openStore = previous })
+	return store
+}
+
+// run executes the command line given by args
+func run(t *testing.T, args ...string) {
+	t.Helper()
+	rootCmd.SetArgs(args)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("backlog %v: %v", args, err)
+	}
+}
+
+// output executes the command line given by args and returns what it
+// printed to stdout
+func output(t *testing.T, args ...string) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	printed := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		printed <- string(data)
+	}()
+	run(t, args...)
+	w.Close()
+	return <-printed
+}
+
+func TestCommandsUseStore(t *testing.T) {
+	store := useMemoryStore(t)
+
+	run(t, "add", "Write tests", "--tags", "dev, ci", "--priority", "P1")
+	backlog, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backlog.Items) != 1 {
+		t.Fatalf("got %d items after add, want 1", len(backlog.Items))
+	}
+	item := backlog.Items[0]
+	if item.Title != "Write tests" || item.Number != 1 || item.Priority != models.PriorityP1 || item.Status != models.StatusTodo {
+		t.Errorf("added item = %+v", item)
+	}
+	if len(item.Tags) != 2 || item.Tags[0] != "dev" || item.Tags[1] != "ci" {
+		t.Errorf("tags = %q, want [dev ci]", item.Tags)
+	}
+
+	run(t, "update", "1", "--status", "in-progress")
+	got, err := store.Get(item.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.StatusInProgress {
+		t.Errorf("status after update = %s, want in-progress", got.Status)
+	}
+
+	run(t, "delete", "1")
+	if _, err := store.Get(item.ID); err != storage.ErrNotFound {
+		t.Errorf("Get after delete: err = %v, want ErrNotFound", err)
+	}
+	trash, err := store.LoadTrash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash.Items) != 1 || trash.Items[0].ID != item.ID {
+		t.Errorf("trash = %+v, want the deleted item", trash.Items)
+	}
+}
@@ -5,88 +5,137 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/filter"
 	"github.com/vvb/backlog/models"
 	"github.com/vvb/backlog/storage"
 )
 
+var searchIncludeArchived bool
+
+// queryHelp describes the query language of the filter package
+const queryHelp = `Queries combine terms, all of which must match:
+
+  word, "exact phrase"   text in the title, description or tags
+  status:todo            status (status:todo,in-progress for either)
+  tag:api                tag
+  priority:P1            priority; priority>=P1 for P1 or more urgent
+  due<2025-12-01         due date (also <=, >, >=, due:today, due:none)
+  created>=2025-11-01    creation or last update date (created, updated)
+  title:word desc:word   text in the title or description only
+
+Prefix a term with - or NOT to exclude it, and use OR and parentheses for
+alternatives: (tag:api OR tag:web) -status:done. A word before ':' that
+isn't a field, as in "re: meeting", is searched for as text.
+
+A query starting with - is taken for a flag unless it follows --, as in
+backlog search -- -tag:wontfix, or is given as --filter=-tag:wontfix.
+NOT tag:wontfix works anywhere.`
+
 var searchCmd = &cobra.Command{
-	Use:   "search [keyword]",
+	Use:   "search [query]",
 	Short: "Search for backlog items",
-	Long:  `Search for backlog items by keyword in title, description, or tags.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Search for backlog items matching a query. A plain keyword is looked for in
+the title, description and tags.
+
+` + queryHelp,
+	Args:        cobra.MinimumNArgs(1),
+	Annotations: printsItems,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		keyword := strings.ToLower(args[0])
+		// The query may be given as one argument or several
+		text := strings.Join(args, " ")
+		expr, err := filter.Parse(text)
+		if err != nil {
+			return err
+		}
 
 		// Create storage
-		store, err := storage.New()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
 
-		// Load backlog
-		backlog, err := store.Load()
+		// Search items, letting the backend do the filtering
+		query := storage.Query{}.WithFilter(expr)
+		matches, err := storage.Find(store, query)
 		if err != nil {
 			return err
 		}
-
-		// Search items
-		matches := []models.BacklogItem{}
-		for _, item := range backlog.Items {
-			if matchesKeyword(item, keyword) {
-				matches = append(matches, item)
+		var archived []models.BacklogItem
+		if searchIncludeArchived {
+			if archived, err = storage.FindArchived(store, query); err != nil {
+				return err
 			}
 		}
 
 		// Display results
-		if len(matches) == 0 {
-			fmt.Printf("No items found matching '%s'\n", args[0])
+		if structuredOutput() {
+			return printItems(append(matches, archived...))
+		}
+		if len(matches) == 0 && len(archived) == 0 {
+			fmt.Printf("No items found matching '%s'\n", text)
 			return nil
 		}
 
-		fmt.Printf("\nFound %d item(s) matching '%s':\n\n", len(matches), args[0])
+		fmt.Printf("\nFound %d item(s) matching '%s':\n\n", len(matches), text)
 		for _, item := range matches {
 			displayItem(item)
 			fmt.Println()
 		}
 
+		if searchIncludeArchived {
+			fmt.Printf("Found %d archived item(s) matching '%s':\n\n", len(archived), text)
+			for _, item := range archived {
+				displayItem(item)
+				fmt.Println()
+			}
+		}
+
 		return nil
 	},
 }
 
-func matchesKeyword(item models.BacklogItem, keyword string) bool {
-	// Check title
-	if strings.Contains(strings.ToLower(item.Title), keyword) {
-		return true
-	}
-
-	// Check description
-	if strings.Contains(strings.ToLower(item.Description), keyword) {
-		return true
-	}
-
-	// Check tags
-	for _, tag := range item.Tags {
-		if strings.Contains(strings.ToLower(tag), keyword) {
-			return true
-		}
-	}
-
-	return false
+func init() {
+	searchCmd.Flags().BoolVar(&searchIncludeArchived, "include-archived", false, "Also search archived items")
 }
 
 func displayItem(item models.BacklogItem) {
-	fmt.Printf("ID: %s\n", truncateID(item.ID))
+	fmt.Printf("ID: %s (%s)\n", item.Ref(), item.ID)
 	fmt.Printf("Title: %s\n", item.Title)
 	if item.Description != "" {
 		fmt.Printf("Description: %s\n", item.Description)
 	}
 	fmt.Printf("Status: %s\n", item.Status)
+	if item.Priority != "" {
+		fmt.Printf("Priority: %s\n", item.Priority)
+	}
 	if item.DueDate != "" {
 		fmt.Printf("Due Date: %s\n", item.DueDate)
 	}
 	if len(item.Tags) > 0 {
 		fmt.Printf("Tags: %s\n", strings.Join(item.Tags, ", "))
 	}
+	if item.Repeat != "" {
+		fmt.Printf("Repeats: %s\n", item.Repeat)
+	}
+	if len(item.Checklist) > 0 {
+		fmt.Printf("Checklist: %s\n", models.ChecklistSummary(item.Checklist))
+		for n, entry := range item.Checklist {
+			fmt.Printf("  %s\n", formatChecklistEntry(n, entry))
+		}
+	}
+	if len(item.Notes) > 0 {
+		fmt.Println("Notes:")
+		for _, note := range item.Notes {
+			fmt.Printf("  %s\n", formatNote(note))
+		}
+	}
+	if len(item.TimeLog) > 0 || item.TimerStarted != nil {
+		spent := formatDuration(item.TimeSpent())
+		if item.TimerStarted != nil {
+			spent += fmt.Sprintf(" (timer running since %s)", item.TimerStarted.Format("02-01-2006 15:04"))
+		}
+		fmt.Printf("Time spent: %s\n", spent)
+	}
 	fmt.Printf("Created: %s\n", item.CreatedAt.Format("02-01-2006 15:04"))
 	fmt.Printf("Updated: %s\n", item.UpdatedAt.Format("02-01-2006 15:04"))
 }
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSearchQueries(t *testing.T) {
+	useMemoryStore(t)
+	run(t, "add", "Fix login")
+	run(t, "add", "Re: meeting notes")
+	run(t, "add", "Drop IE support", "--tags", "wontfix")
+	t.Cleanup(func() { addTags = "" })
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		// A leading - needs -- so cobra doesn't take the query for flags
+		{[]string{"search", "--", "-tag:wontfix"}, []string{"Fix login", "Re: meeting notes"}},
+		{[]string{"search", "NOT", "tag:wontfix"}, []string{"Fix login", "Re: meeting notes"}},
+		// re: isn't a field, so it is text
+		{[]string{"search", "re: meeting"}, []string{"Re: meeting notes"}},
+	}
+	for _, tt := range tests {
+		printed := output(t, tt.args...)
+		for _, title := range []string{"Fix login", "Re: meeting notes", "Drop IE support"} {
+			want := false
+			for _, w := range tt.want {
+				want = want || w == title
+			}
+			if got := strings.Contains(printed, title); got != want {
+				t.Errorf("%q: listed %q = %v, want %v\n%s", tt.args, title, got, want, printed)
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/storage"
+)
+
+var migrateTo string
+
+var storageCmd = &cobra.Command{
+	Use:   "storage",
+	Short: "Manage the storage backend of the current board",
+}
+
+var storageMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Convert the current board to another storage backend",
+	Long: `Convert the current board's items and archive to another storage backend
+(json or sqlite). The copy is read back and compared with the original before
+the board is switched over, and the old files are kept with a .bak suffix.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := currentBoardDir()
+		if err != nil {
+			return err
+		}
+
+		result, err := storage.ConvertBoard(dir, migrateTo)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Migrated %d item(s), %d archived item(s) and %d trashed item(s) from %s to %s\n",
+			result.Items, result.Archived, result.Trashed, result.From, result.To)
+		for _, backup := range result.Backups {
+			fmt.Printf("  Kept old data as %s\n", backup)
+		}
+		return nil
+	},
+}
+
+func init() {
+	storageMigrateCmd.Flags().StringVar(&migrateTo, "to", "", "Target backend (json or sqlite)")
+	storageMigrateCmd.MarkFlagRequired("to")
+
+	storageCmd.AddCommand(storageMigrateCmd)
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/models"
+)
+
+var (
+	startMove bool
+	logDate   string
+)
+
+var startCmd = &cobra.Command{
+	Use:   "start [id]",
+	Short: "Start a timer on an item",
+	Long: `Start tracking time on an item. Only one timer runs at a time: a timer
+running on another item is stopped first and its time logged.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workflow, err := boardWorkflow()
+		if err != nil {
+			return err
+		}
+		if startMove && !workflow.Has(models.StatusInProgress) {
+			return fmt.Errorf("the board's workflow has no %s status to move the item to", models.StatusInProgress)
+		}
+
+		// Create storage
+		store, unlock, err := openLockedStore()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		// Load backlog
+		backlog, err := store.Load()
+		if err != nil {
+			return err
+		}
+
+		// Find item
+		i, err := models.Resolve(backlog.Items, args[0])
+		if err != nil {
+			return err
+		}
+		item := &backlog.Items[i]
+		if item.TimerStarted != nil {
+			return fmt.Errorf("a timer is already running on %s %s", item.Ref(), item.Title)
+		}
+
+		var warnings []string
+		if startMove && item.Status != models.StatusInProgress {
+			if err := workflow.CheckMove(item.Status, models.StatusInProgress); err != nil {
+				return err
+			}
+			warning, err := checkWIPLimit(workflow, backlog.Items, models.StatusInProgress)
+			if err != nil {
+				return err
+			}
+			if warning != "" {
+				warnings = append(warnings, warning)
+			}
+			if warning := blockedWarning(backlog.Items, workflow, *item, models.StatusInProgress); warning != "" {
+				warnings = append(warnings, warning)
+			}
+			item.Rank = models.RankLast(backlog.Items, models.StatusInProgress, item.ID)
+			item.Status = models.StatusInProgress
+		}
+
+		// Stop the timer running on another item
+		now := time.Now()
+		var stopped *models.BacklogItem
+		var entry models.TimeEntry
+		if j := models.RunningTimer(backlog.Items); j >= 0 {
+			stopped = &backlog.Items[j]
+			entry, _ = stopped.StopTimer(now)
+			stopped.UpdatedAt = now
+		}
+
+		item.TimerStarted = &now
+		item.UpdatedAt = now
+
+		// Save
+		if err := store.Save(backlog); err != nil {
+			return err
+		}
+
+		if stopped != nil {
+			fmt.Printf("✓ Stopped timer on %s after %s\n", stopped.Title, formatDuration(entry.Duration()))
+		}
+		fmt.Printf("✓ Started timer on %s\n", item.Title)
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "⚠ %s\n", warning)
+		}
+		return nil
+	},
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running timer",
+	Long:  `Stop the running timer and log the time spent on its item.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Create storage
+		store, unlock, err := openLockedStore()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		// Load backlog
+		backlog, err := store.Load()
+		if err != nil {
+			return err
+		}
+
+		i := models.RunningTimer(backlog.Items)
+		if i < 0 {
+			return fmt.Errorf("no timer is running")
+		}
+		item := &backlog.Items[i]
+		now := time.Now()
+		entry, _ := item.StopTimer(now)
+		item.UpdatedAt = now
+
+		// Save
+		if err := store.Save(backlog); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Stopped timer on %s after %s (%s in total)\n", item.Title, formatDuration(entry.Duration()), formatDuration(item.TimeSpent()))
+		return nil
+	},
+}
+
+var logCmd = &cobra.Command{
+	Use:   "log [id] [duration]",
+	Short: "Log time spent on an item",
+	Long:  `Log time spent on an item by hand, e.g. 1h30m or 45m.`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := time.ParseDuration(args[1])
+		if err != nil || d < time.Minute {
+			return fmt.Errorf("invalid duration %q (e.g. 1h30m, 45m)", args[1])
+		}
+
+		// The entry ends now, or at the end of the working day it was
+		// logged for
+		now := time.Now()
+		end := now
+		if logDate != "" {
+			date, err := time.ParseInLocation("02-01-2006", logDate, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid date format. Use DD-MM-YYYY")
+			}
+			end = date.Add(17 * time.Hour)
+		}
+
+		// Create storage
+		store, unlock, err := openLockedStore()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		// Load backlog
+		backlog, err := store.Load()
+		if err != nil {
+			return err
+		}
+
+		// Find item
+		i, err := models.Resolve(backlog.Items, args[0])
+		if err != nil {
+			return err
+		}
+		item := &backlog.Items[i]
+		item.LogTime(models.NewTimeEntry(end.Add(-d), d, true))
+		item.UpdatedAt = now
+
+		// Save
+		if err := store.Save(backlog); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Logged %s on %s (%s in total)\n", formatDuration(d), item.Title, formatDuration(item.TimeSpent()))
+		return nil
+	},
+}
+
+func init() {
+	startCmd.Flags().BoolVar(&startMove, "move", false, "Also move the item to in-progress")
+	logCmd.Flags().StringVar(&logDate, "date", "", "Day the time was spent, in DD-MM-YYYY format (default today)")
+}
+
+// formatDuration renders a duration to the minute, like "1h30m", "2h" or
+// "45m"
+func formatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	switch {
+	case minutes < 60:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("%dh", minutes/60)
+	default:
+		return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vvb/backlog/models"
+	"github.com/vvb/backlog/storage"
+)
+
+// timedItem returns an in-progress item whose timer was started an hour ago
+func timedItem() models.BacklogItem {
+	started := time.Now().Add(-time.Hour)
+	return models.BacklogItem{ID: "a", Title: "Timed", Status: models.StatusInProgress, CreatedAt: started, TimerStarted: &started}
+}
+
+// checkTimerStopped fails unless item's timer is stopped with about an
+// hour logged
+func checkTimerStopped(t *testing.T, item models.BacklogItem) {
+	t.Helper()
+	if item.TimerStarted != nil {
+		t.Errorf("timer on %s is still running", item.Title)
+	}
+	if spent := item.TimeSpent(); spent < 59*time.Minute || spent > 61*time.Minute {
+		t.Errorf("logged %s on %s, want the hour its timer ran", spent, item.Title)
+	}
+}
+
+func TestTimerStopsWhenItemLeavesTheBoard(t *testing.T) {
+	tests := []struct {
+		name  string
+		leave func(store storage.Store, backlog *models.Backlog) error
+		load  func(store storage.Store) (*models.Backlog, error)
+	}{
+		{
+			"archive",
+			func(store storage.Store, backlog *models.Backlog) error {
+				backlog.Items[0].Status = models.StatusDone
+				return archiveItems(store, backlog, backlog.Items)
+			},
+			storage.Store.LoadArchive,
+		},
+		{
+			"trash",
+			func(store storage.Store, backlog *models.Backlog) error {
+				_, err := trashItem(store, backlog, "a")
+				return err
+			},
+			storage.Store.LoadTrash,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemory()
+			if err := store.Save(&models.Backlog{Items: []models.BacklogItem{timedItem()}}); err != nil {
+				t.Fatal(err)
+			}
+			backlog, err := store.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.leave(store, backlog); err != nil {
+				t.Fatal(err)
+			}
+
+			moved, err := tt.load(store)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(moved.Items) != 1 {
+				t.Fatalf("%s has %d items, want 1", tt.name, len(moved.Items))
+			}
+			checkTimerStopped(t, moved.Items[0])
+		})
+	}
+}
+
+func TestTimerStopsWhenItemIsDone(t *testing.T) {
+	store := useMemoryStore(t)
+	if err := store.Save(&models.Backlog{Items: []models.BacklogItem{timedItem()}}); err != nil {
+		t.Fatal(err)
+	}
+
+	run(t, "update", "a", "--status", "done")
+	t.Cleanup(func() { updateStatus = "" })
+
+	item, err := store.Get("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTimerStopped(t, *item)
+}
+
+func TestInteractiveTimerStopsWhenItemIsDone(t *testing.T) {
+	store := useMemoryStore(t)
+	if err := store.Save(&models.Backlog{Items: []models.BacklogItem{timedItem()}}); err != nil {
+		t.Fatal(err)
+	}
+	backlog, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The board opens on the in-progress column, with the item selected
+	m := initialModel(backlog, &models.Backlog{}, store, models.DefaultWorkflow())
+	msg, ok := m.moveItemToStatus(models.StatusDone)().(moveItemMsg)
+	if !ok || msg.err != nil {
+		t.Fatalf("moving the item to done: %+v", msg)
+	}
+	if msg.stopped == nil {
+		t.Error("the move didn't report the stopped timer")
+	}
+
+	item, err := store.Get("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTimerStopped(t, *item)
+}
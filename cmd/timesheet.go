@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/models"
+)
+
+var timesheetWeek bool
+
+// untaggedGroup is the timesheet group of items without tags
+const untaggedGroup = "(untagged)"
+
+var timesheetCmd = &cobra.Command{
+	Use:   "timesheet",
+	Short: "Report the time spent on items, grouped by tag",
+	Long: `Report the time logged on items, grouped by tag. Items with several tags
+are listed under each of them; the total counts their time once. Archived
+items are included, and so is the running timer.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		now := time.Now()
+		var from, to time.Time
+		if timesheetWeek {
+			from = startOfWeek(now)
+			to = from.AddDate(0, 0, 7)
+		}
+
+		// Create storage
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+
+		// Load backlog and archive
+		backlog, err := store.Load()
+		if err != nil {
+			return err
+		}
+		archive, err := store.LoadArchive()
+		if err != nil {
+			return err
+		}
+		items := append(append([]models.BacklogItem(nil), backlog.Items...), archive.Items...)
+
+		// Add up the time per item
+		spent := make(map[string]time.Duration)
+		var total time.Duration
+		for _, item := range items {
+			entries := item.TimeLog
+			if item.TimerStarted != nil {
+				entries = append(append([]models.TimeEntry(nil), entries...), models.NewTimeEntry(*item.TimerStarted, now.Sub(*item.TimerStarted), false))
+			}
+			for _, entry := range entries {
+				if timesheetWeek && (entry.Start.Before(from) || !entry.Start.Before(to)) {
+					continue
+				}
+				spent[item.ID] += entry.Duration()
+				total += entry.Duration()
+			}
+		}
+
+		if timesheetWeek {
+			fmt.Printf("\nTimesheet for %s to %s\n\n", from.Format("02-01-2006"), to.AddDate(0, 0, -1).Format("02-01-2006"))
+		} else {
+			fmt.Printf("\nTimesheet\n\n")
+		}
+		if total == 0 {
+			fmt.Println("No time logged")
+			fmt.Println()
+			return nil
+		}
+
+		// Group the items by tag
+		groups := make(map[string][]models.BacklogItem)
+		for _, item := range items {
+			if spent[item.ID] == 0 {
+				continue
+			}
+			if len(item.Tags) == 0 {
+				groups[untaggedGroup] = append(groups[untaggedGroup], item)
+			}
+			for _, tag := range item.Tags {
+				groups[tag] = append(groups[tag], item)
+			}
+		}
+		var tags []string
+		for tag := range groups {
+			if tag != untaggedGroup {
+				tags = append(tags, tag)
+			}
+		}
+		sort.Strings(tags)
+		if groups[untaggedGroup] != nil {
+			tags = append(tags, untaggedGroup)
+		}
+
+		for _, tag := range tags {
+			group := groups[tag]
+			sort.SliceStable(group, func(a, b int) bool { return spent[group[a].ID] > spent[group[b].ID] })
+
+			var subtotal time.Duration
+			for _, item := range group {
+				subtotal += spent[item.ID]
+			}
+			fmt.Printf("%-42s %8s\n", truncate(tag, 42), formatDuration(subtotal))
+			for _, item := range group {
+				fmt.Printf("  %-6s %-33s %8s\n", item.Ref(), truncate(item.Title, 33), formatDuration(spent[item.ID]))
+			}
+		}
+		fmt.Printf("\n%-42s %8s\n", "Total", formatDuration(total))
+
+		if i := models.RunningTimer(backlog.Items); i >= 0 {
+			fmt.Printf("\n⏱ Includes the timer running on %s %s\n", backlog.Items[i].Ref(), backlog.Items[i].Title)
+		}
+		fmt.Println()
+		return nil
+	},
+}
+
+func init() {
+	timesheetCmd.Flags().BoolVar(&timesheetWeek, "week", false, "Only count time spent this week (Monday to Sunday)")
+}
+
+// startOfWeek returns midnight on the Monday of t's week
+func startOfWeek(t time.Time) time.Time {
+	days := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-days, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/models"
+	"github.com/vvb/backlog/storage"
+)
+
+var trashOlderThan string
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Manage deleted items",
+	Long:  `Deleted items are kept in the trash until it is emptied, and can be restored from there.`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List deleted items",
+	Annotations: printsItems,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Create storage
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+
+		// Load trash
+		trash, err := store.LoadTrash()
+		if err != nil {
+			return err
+		}
+
+		// Most recently deleted first
+		items := trash.Items
+		sort.SliceStable(items, func(i, j int) bool {
+			return deletedAt(items[i]).After(deletedAt(items[j]))
+		})
+
+		if structuredOutput() {
+			return printItems(items)
+		}
+		if len(items) == 0 {
+			fmt.Println("Trash is empty")
+			return nil
+		}
+
+		fmt.Printf("\nTrash (%d item(s))\n\n", len(items))
+		for _, item := range items {
+			fmt.Printf("%-6s %s  (deleted %s)\n", item.Ref(), item.Title, deletedAt(item).Format("02-01-2006 15:04"))
+		}
+		fmt.Println()
+
+		return nil
+	},
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore [id]",
+	Short: "Restore a deleted item",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id := args[0]
+
+		// Create storage
+		store, unlock, err := openLockedStore()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		// Load backlog and trash
+		backlog, err := store.Load()
+		if err != nil {
+			return err
+		}
+		trash, err := store.LoadTrash()
+		if err != nil {
+			return err
+		}
+
+		i, err := models.Resolve(trash.Items, id)
+		if err != nil {
+			return err
+		}
+		item := trash.Items[i]
+		item.DeletedAt = nil
+
+		// An item left in the trash by an interrupted delete may still be
+		// on the board, which keeps the copy there
+		active := indexByID(backlog.Items, item.ID) >= 0
+
+		// Save the backlog first so a failure in between never loses the
+		// item
+		if !active {
+			backlog.Items = append(backlog.Items, item)
+			if err := store.Save(backlog); err != nil {
+				return err
+			}
+		}
+
+		trash.Items = append(trash.Items[:i], trash.Items[i+1:]...)
+		if err := store.SaveTrash(trash); err != nil {
+			return err
+		}
+
+		if active {
+			fmt.Printf("✓ %s was already on the board; removed it from the trash\n", item.Title)
+			return nil
+		}
+		fmt.Printf("✓ Restored backlog item: %s\n", item.Title)
+		return nil
+	},
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Permanently remove deleted items",
+	Long: `Permanently remove items from the trash. With --older-than, only items
+deleted longer ago than the given age (e.g. 30d, 2w, 12h) are removed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var cutoff time.Time
+		if trashOlderThan != "" {
+			age, err := parseAge(trashOlderThan)
+			if err != nil {
+				return err
+			}
+			cutoff = time.Now().Add(-age)
+		}
+
+		// Create storage
+		store, unlock, err := openLockedStore()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		// Load trash
+		trash, err := store.LoadTrash()
+		if err != nil {
+			return err
+		}
+
+		kept := []models.BacklogItem{}
+		for _, item := range trash.Items {
+			if !cutoff.IsZero() && deletedAt(item).After(cutoff) {
+				kept = append(kept, item)
+			}
+		}
+		removed := len(trash.Items) - len(kept)
+
+		if removed == 0 {
+			fmt.Println("No items to remove from the trash")
+			return nil
+		}
+
+		trash.Items = kept
+		if err := store.SaveTrash(trash); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Permanently removed %d item(s) from the trash\n", removed)
+		return nil
+	},
+}
+
+func init() {
+	trashEmptyCmd.Flags().StringVar(&trashOlderThan, "older-than", "", "Only remove items deleted longer ago than this (e.g. 30d)")
+
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashRestoreCmd)
+	trashCmd.AddCommand(trashEmptyCmd)
+}
+
+// trashItem moves the item with the given ID from backlog to the trash and
+// saves both. The trash is saved first so a failure in between never loses
+// the item. The caller should hold the store lock.
+func trashItem(store storage.Store, backlog *models.Backlog, id string) (*models.BacklogItem, error) {
+	i := -1
+	for j := range backlog.Items {
+		if backlog.Items[j].ID == id {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return nil, fmt.Errorf("item with ID %s not found", id)
+	}
+	item := backlog.Items[i]
+
+	trash, err := store.LoadTrash()
+	if err != nil {
+		return nil, err
+	}
+	// A timer can't keep running in the trash; log the time spent so far
+	deleted := item
+	now := time.Now()
+	deleted.StopTimer(now)
+	deleted.DeletedAt = &now
+	trash.Items = append(trash.Items, deleted)
+	if err := store.SaveTrash(trash); err != nil {
+		return nil, err
+	}
+
+	// Take the item back out of the trash if the backlog can't be saved,
+	// so it doesn't end up in both
+	items := backlog.Items
+	backlog.Items = append(items[:i:i], items[i+1:]...)
+	if err := store.Save(backlog); err != nil {
+		backlog.Items = items
+		trash.Items = trash.Items[:len(trash.Items)-1]
+		if rollbackErr := store.SaveTrash(trash); rollbackErr != nil {
+			return nil, fmt.Errorf("%w (and failed to take %s back out of the trash: %v)", err, item.Ref(), rollbackErr)
+		}
+		return nil, err
+	}
+
+	return &deleted, nil
+}
+
+// deletedAt returns when an item was moved to the trash
+func deletedAt(item models.BacklogItem) time.Time {
+	if item.DeletedAt == nil {
+		return item.UpdatedAt
+	}
+	return *item.DeletedAt
+}
+
+// parseAge parses an age such as 30d, 2w or 12h. Days and weeks are
+// accepted on top of the units time.ParseDuration understands.
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid age %q (e.g. 30d, 2w, 12h)", s)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q (e.g. 30d, 2w, 12h)", s)
+	}
+	return age, nil
+}
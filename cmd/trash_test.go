@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vvb/backlog/models"
+	"github.com/vvb/backlog/storage"
+)
+
+// conflictingStore fails every save of the active backlog, as a store
+// does when another process changed the board since it was loaded
+type conflictingStore struct {
+	*storage.MemoryStore
+}
+
+func (s conflictingStore) Save(*models.Backlog) error {
+	return storage.ErrConflict
+}
+
+func TestTrashItemRollsBackOnConflict(t *testing.T) {
+	memory := storage.NewMemory()
+	item := models.BacklogItem{ID: "a", Title: "Keep me", Status: models.StatusTodo, CreatedAt: time.Now()}
+	if err := memory.Save(&models.Backlog{Items: []models.BacklogItem{item}}); err != nil {
+		t.Fatal(err)
+	}
+	backlog, err := memory.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := trashItem(conflictingStore{memory}, backlog, "a"); !errors.Is(err, storage.ErrConflict) {
+		t.Fatalf("trashItem: err = %v, want ErrConflict", err)
+	}
+	trash, err := memory.LoadTrash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash.Items) != 0 {
+		t.Errorf("trash = %+v, want the item taken back out", trash.Items)
+	}
+	if len(backlog.Items) != 1 {
+		t.Errorf("the caller's backlog lost the item: %+v", backlog.Items)
+	}
+}
+
+func TestTrashRestoreSkipsItemsStillOnTheBoard(t *testing.T) {
+	store := useMemoryStore(t)
+	run(t, "add", "Twice")
+	backlog, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleted := backlog.Items[0]
+	now := time.Now()
+	deleted.DeletedAt = &now
+	if err := store.SaveTrash(&models.Backlog{Items: []models.BacklogItem{deleted}}); err != nil {
+		t.Fatal(err)
+	}
+
+	run(t, "trash", "restore", "1")
+
+	if backlog, err = store.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(backlog.Items) != 1 {
+		t.Errorf("backlog has %d items after restoring one that was still there, want 1", len(backlog.Items))
+	}
+	trash, err := store.LoadTrash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash.Items) != 0 {
+		t.Errorf("trash = %+v, want it emptied", trash.Items)
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/models"
+)
+
+var treeCmd = &cobra.Command{
+	Use:   "tree [id]",
+	Short: "Show items grouped under their epics",
+	Long: `Show the item hierarchy: each epic with the items below it, and how many of
+those are done. Progress counts every level below an epic, sub-epics and
+their items included, and archived items count as done. With an ID, only that
+item and the items below it are shown.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workflow, err := boardWorkflow()
+		if err != nil {
+			return err
+		}
+
+		// Create storage
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+
+		// Load backlog and archive
+		backlog, err := store.Load()
+		if err != nil {
+			return err
+		}
+		archive, err := store.LoadArchive()
+		if err != nil {
+			return err
+		}
+
+		items := backlog.Items
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].Number < items[j].Number
+		})
+
+		var roots []models.BacklogItem
+		if len(args) == 1 {
+			i, err := models.Resolve(items, args[0])
+			if err != nil {
+				return err
+			}
+			roots = []models.BacklogItem{items[i]}
+		} else {
+			// Items whose epic is gone (archived or deleted) are roots too
+			ids := map[string]bool{}
+			for _, item := range items {
+				ids[item.ID] = true
+			}
+			for _, item := range items {
+				if !ids[item.Parent] {
+					roots = append(roots, item)
+				}
+			}
+		}
+
+		if len(roots) == 0 {
+			fmt.Println("No backlog items")
+			return nil
+		}
+
+		fmt.Println()
+		printed := map[string]bool{}
+		for _, root := range roots {
+			printTree(items, archive.Items, workflow, root, "", "", printed)
+		}
+		fmt.Println()
+
+		return nil
+	},
+}
+
+// printTree prints item and, indented below it, its descendants. prefix
+// starts the item's own line and indent the lines of its children. Archived
+// items only count towards progress.
+func printTree(items, archived []models.BacklogItem, workflow *models.Workflow, item models.BacklogItem, prefix, indent string, printed map[string]bool) {
+	line := fmt.Sprintf("%s%s %s  [%s]", prefix, item.Ref(), item.Title, item.Status)
+	if done, total := models.ChildProgress(items, archived, item.ID, workflow.Done); total > 0 {
+		line += fmt.Sprintf("  (%d/%d done)", done, total)
+	}
+	fmt.Println(line)
+
+	// Guard against loops in hand-edited data
+	if printed[item.ID] {
+		return
+	}
+	printed[item.ID] = true
+
+	children := models.Children(items, item.ID)
+	for i, child := range children {
+		if i == len(children)-1 {
+			printTree(items, archived, workflow, child, indent+"└── ", indent+"    ", printed)
+		} else {
+			printTree(items, archived, workflow, child, indent+"├── ", indent+"│   ", printed)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/journal"
+)
+
+// undoer is implemented by stores that journal their changes
+type undoer interface {
+	Undo() (*journal.Tx, error)
+	Redo() (*journal.Tx, error)
+}
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo the last change",
+	Long:  `Revert the most recent change to the board, as recorded in its journal. Run it repeatedly to step further back.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openUndoer()
+		if err != nil {
+			return err
+		}
+
+		tx, err := store.Undo()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Undid: %s\n", tx.Describe())
+		return nil
+	},
+}
+
+var redoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "Redo the last undone change",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openUndoer()
+		if err != nil {
+			return err
+		}
+
+		tx, err := store.Redo()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Redid: %s\n", tx.Describe())
+		return nil
+	},
+}
+
+// openUndoer opens the current board without applying its auto-archive
+// policy, so undo and redo don't start by recording a change of their own
+func openUndoer() (undoer, error) {
+	store, _, err := openJournaledBoard()
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
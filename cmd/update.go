@@ -2,33 +2,56 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
+	"os"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/vvb/backlog/models"
-	"github.com/vvb/backlog/storage"
 )
 
 var (
-	updateTitle  string
-	updateDesc   string
-	updateDue    string
-	updateTags   string
-	updateStatus string
+	updateTitle    string
+	updateDesc     string
+	updateDue      string
+	updateTags     string
+	updateStatus   string
+	updatePriority string
+	updateParent   string
+	updateRepeat   string
 )
 
 var updateCmd = &cobra.Command{
 	Use:   "update [id]",
 	Short: "Update a backlog item",
-	Long:  `Update a backlog item's title, description, due date, tags, or status.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Update a backlog item's title, description, due date, tags, status, priority,
+parent epic, or recurrence rule. Moving a recurring item to done creates
+its next instance.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: printsItems,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		id := args[0]
 
 		// Validate status if provided
-		if updateStatus != "" && !models.ValidStatus(updateStatus) {
-			return fmt.Errorf("invalid status. Use: todo, in-progress, or done")
+		workflow, err := boardWorkflow()
+		if err != nil {
+			return err
+		}
+		if updateStatus != "" {
+			if err := workflow.CheckStatus(models.Status(updateStatus)); err != nil {
+				return err
+			}
+		}
+
+		// Validate priority if provided
+		priority, err := models.ParsePriority(updatePriority)
+		if err != nil {
+			return err
+		}
+
+		// Validate recurrence rule if provided
+		repeat, err := parseRepeat(updateRepeat)
+		if err != nil {
+			return err
 		}
 
 		// Validate due date format if provided
@@ -37,10 +60,11 @@ var updateCmd = &cobra.Command{
 		}
 
 		// Create storage
-		store, err := storage.New()
+		store, unlock, err := openLockedStore()
 		if err != nil {
 			return err
 		}
+		defer unlock()
 
 		// Load backlog
 		backlog, err := store.Load()
@@ -49,48 +73,105 @@ var updateCmd = &cobra.Command{
 		}
 
 		// Find item
-		found := false
-		for i := range backlog.Items {
-			if strings.HasPrefix(backlog.Items[i].ID, id) {
-				found = true
-
-				// Update fields
-				if updateTitle != "" {
-					backlog.Items[i].Title = updateTitle
-				}
-				if updateDesc != "" {
-					backlog.Items[i].Description = updateDesc
-				}
-				if updateDue != "" {
-					backlog.Items[i].DueDate = updateDue
-				}
-				if updateTags != "" {
-					tags := strings.Split(updateTags, ",")
-					for j := range tags {
-						tags[j] = strings.TrimSpace(tags[j])
-					}
-					backlog.Items[i].Tags = tags
-				}
-				if updateStatus != "" {
-					backlog.Items[i].Status = models.Status(updateStatus)
-				}
-
-				backlog.Items[i].UpdatedAt = time.Now()
-
-				// Save
-				if err := store.Save(backlog); err != nil {
-					return err
-				}
-
-				fmt.Printf("✓ Updated backlog item: %s\n", backlog.Items[i].Title)
-				break
+		i, err := models.Resolve(backlog.Items, id)
+		if err != nil {
+			return err
+		}
+
+		// Update fields
+		if updateTitle != "" {
+			backlog.Items[i].Title = updateTitle
+		}
+		if updateDesc != "" {
+			backlog.Items[i].Description = updateDesc
+		}
+		if updateDue != "" {
+			backlog.Items[i].DueDate = updateDue
+		}
+		if updateTags != "" {
+			backlog.Items[i].Tags = splitTags(updateTags)
+		}
+		var warnings []string
+		completed := false
+		if updateStatus != "" && models.Status(updateStatus) != backlog.Items[i].Status {
+			status := models.Status(updateStatus)
+			if err := workflow.CheckMove(backlog.Items[i].Status, status); err != nil {
+				return err
+			}
+			warning, err := checkWIPLimit(workflow, backlog.Items, status)
+			if err != nil {
+				return err
+			}
+			if warning != "" {
+				warnings = append(warnings, warning)
 			}
+			if warning := blockedWarning(backlog.Items, workflow, backlog.Items[i], status); warning != "" {
+				warnings = append(warnings, warning)
+			}
+			backlog.Items[i].Rank = models.RankLast(backlog.Items, status, backlog.Items[i].ID)
+			backlog.Items[i].Status = status
+			completed = status == workflow.Done
+		}
+		if updatePriority != "" {
+			backlog.Items[i].Priority = priority
+		}
+		if updateParent == "none" {
+			backlog.Items[i].Parent = ""
+		} else if updateParent != "" {
+			j, err := models.Resolve(backlog.Items, updateParent)
+			if err != nil {
+				return fmt.Errorf("parent: %w", err)
+			}
+			if err := models.CheckParent(backlog.Items, backlog.Items[i], backlog.Items[j]); err != nil {
+				return err
+			}
+			backlog.Items[i].Parent = backlog.Items[j].ID
+		}
+		if updateRepeat != "" {
+			backlog.Items[i].Repeat = repeat
 		}
 
-		if !found {
-			return fmt.Errorf("item with ID %s not found", id)
+		now := time.Now()
+		backlog.Items[i].UpdatedAt = now
+
+		// Completing the item stops its timer and schedules the next
+		// instance of a recurring item
+		next := -1
+		var stopped *models.TimeEntry
+		if completed {
+			if stopped, next, err = completeItem(backlog, workflow, i, now); err != nil {
+				return err
+			}
+		}
+
+		// Save
+		if err := store.Save(backlog); err != nil {
+			return err
 		}
 
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "⚠ %s\n", warning)
+		}
+
+		if structuredOutput() {
+			// Scripts get the updated item; the stopped timer and the next
+			// instance are reported on stderr so stdout stays a single item
+			if stopped != nil {
+				fmt.Fprintf(os.Stderr, "✓ Stopped timer on %s after %s\n", backlog.Items[i].Title, formatDuration(stopped.Duration()))
+			}
+			if next >= 0 {
+				fmt.Fprintf(os.Stderr, "✓ Created next instance: %s (%s), due %s\n", backlog.Items[next].Title, backlog.Items[next].Ref(), backlog.Items[next].DueDate)
+			}
+			return printItem(backlog.Items[i])
+		}
+
+		fmt.Printf("✓ Updated backlog item: %s\n", backlog.Items[i].Title)
+		if stopped != nil {
+			fmt.Printf("✓ Stopped timer on %s after %s\n", backlog.Items[i].Title, formatDuration(stopped.Duration()))
+		}
+		if next >= 0 {
+			fmt.Printf("✓ Created next instance: %s (%s), due %s\n", backlog.Items[next].Title, backlog.Items[next].Ref(), backlog.Items[next].DueDate)
+		}
 		return nil
 	},
 }
@@ -100,5 +181,25 @@ func init() {
 	updateCmd.Flags().StringVar(&updateDesc, "desc", "", "New description")
 	updateCmd.Flags().StringVar(&updateDue, "due", "", "New due date in DD-MM-YYYY format")
 	updateCmd.Flags().StringVar(&updateTags, "tags", "", "New comma-separated tags")
-	updateCmd.Flags().StringVar(&updateStatus, "status", "", "New status (todo, in-progress, done)")
+	updateCmd.Flags().StringVar(&updateStatus, "status", "", "New status (one of the board's workflow statuses, see 'backlog board workflow')")
+	updateCmd.Flags().StringVar(&updatePriority, "priority", "", "New priority (P0, P1, P2, P3, or none to clear it)")
+	updateCmd.Flags().StringVar(&updateParent, "parent", "", "ID of the epic the item belongs to, or none to detach it")
+	updateCmd.Flags().StringVar(&updateRepeat, "repeat", "", "Repeat the item (daily, weekly, weekly:mon,thu, monthly or every:3d), or none to stop")
+}
+
+// completeItem handles the item at index i having just moved to the
+// workflow's done status: a timer running on it is stopped and its time
+// logged, and a recurring item gets its next instance. It returns the
+// logged entry, if a timer was running, and the index of the next
+// instance, or -1.
+func completeItem(backlog *models.Backlog, workflow *models.Workflow, i int, now time.Time) (*models.TimeEntry, int, error) {
+	var stopped *models.TimeEntry
+	if entry, ok := backlog.Items[i].StopTimer(now); ok {
+		stopped = &entry
+	}
+	next, err := repeatItem(backlog, workflow, i, now)
+	if err != nil {
+		return nil, -1, err
+	}
+	return stopped, next, nil
 }
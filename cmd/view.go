@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/filter"
+	"github.com/vvb/backlog/models"
+	"github.com/vvb/backlog/storage"
+)
+
+var viewSort string
+
+var viewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Manage saved views",
+	Long: `Manage the board's saved views: named queries, with the order to show the
+matching items in. Show one with 'backlog list --view <name>', or press v in
+interactive mode to switch between them.`,
+}
+
+var viewSaveCmd = &cobra.Command{
+	Use:   "save [name] [query]",
+	Short: "Save a query as a view",
+	Long: `Save a query as a view, replacing any view with the same name. The query
+may be given as one argument or several.
+
+` + queryHelp,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := strings.TrimSpace(args[0])
+		if name == "" {
+			return fmt.Errorf("view name is required")
+		}
+		query := strings.Join(args[1:], " ")
+		if _, err := filter.Parse(query); err != nil {
+			return err
+		}
+		if viewSort != "" {
+			if err := models.CheckSortOrder(viewSort); err != nil {
+				return err
+			}
+		}
+
+		dir, err := currentBoardDir()
+		if err != nil {
+			return err
+		}
+		config, err := storage.LoadBoardConfig(dir)
+		if err != nil {
+			return err
+		}
+
+		view := storage.View{Name: name, Query: query, Sort: viewSort}
+		verb := "Saved"
+		if existing := config.FindView(name); existing != nil {
+			*existing = view
+			verb = "Updated"
+		} else {
+			config.Views = append(config.Views, view)
+		}
+
+		if err := storage.SaveBoardConfig(dir, config); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ %s view %s: %s%s\n", verb, name, query, sortSuffix(view.Sort))
+		return nil
+	},
+}
+
+var viewListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the board's views",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := currentBoardDir()
+		if err != nil {
+			return err
+		}
+		config, err := storage.LoadBoardConfig(dir)
+		if err != nil {
+			return err
+		}
+
+		if len(config.Views) == 0 {
+			fmt.Println("No saved views (save one with 'backlog view save <name> <query>')")
+			return nil
+		}
+
+		fmt.Printf("\n%-3s %-16s %-10s %s\n", "#", "NAME", "SORT", "QUERY")
+		for i, view := range config.Views {
+			sort := view.Sort
+			if sort == "" {
+				sort = "rank"
+			}
+			fmt.Printf("%-3d %-16s %-10s %s\n", i+1, view.Name, sort, view.Query)
+		}
+		fmt.Println()
+		return nil
+	},
+}
+
+var viewDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete a view",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := currentBoardDir()
+		if err != nil {
+			return err
+		}
+		config, err := storage.LoadBoardConfig(dir)
+		if err != nil {
+			return err
+		}
+
+		views := config.Views[:0]
+		for _, view := range config.Views {
+			if view.Name != args[0] {
+				views = append(views, view)
+			}
+		}
+		if len(views) == len(config.Views) {
+			return fmt.Errorf("no view named %q (see 'backlog view list')", args[0])
+		}
+		config.Views = views
+
+		if err := storage.SaveBoardConfig(dir, config); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Deleted view %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	viewSaveCmd.Flags().StringVar(&viewSort, "sort", "", "Order to show the items in: "+strings.Join(models.SortOrders, ", ")+" (default rank)")
+	viewCmd.AddCommand(viewSaveCmd)
+	viewCmd.AddCommand(viewListCmd)
+	viewCmd.AddCommand(viewDeleteCmd)
+}
+
+// boardViews returns the views of the current board
+func boardViews() ([]storage.View, error) {
+	dir, err := currentBoardDir()
+	if err != nil {
+		return nil, err
+	}
+	config, err := storage.LoadBoardConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	return config.Views, nil
+}
+
+// findView returns the view with the given name
+func findView(views []storage.View, name string) (*storage.View, error) {
+	for i := range views {
+		if views[i].Name == name {
+			return &views[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no view named %q (see 'backlog view list')", name)
+}
+
+// viewQuery combines a view's query with an extra one, either of which
+// may be empty. Both are grouped, so an OR in either can't swallow the
+// other.
+func viewQuery(view, extra string) string {
+	switch {
+	case view == "":
+		return extra
+	case extra == "":
+		return view
+	}
+	return "(" + view + ") (" + extra + ")"
+}
+
+// sortSuffix describes a sort order for messages, like " (sorted by due)"
+func sortSuffix(order string) string {
+	if order == "" || order == "rank" {
+		return ""
+	}
+	return fmt.Sprintf(" (sorted by %s)", order)
+}
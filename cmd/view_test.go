@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/vvb/backlog/filter"
+	"github.com/vvb/backlog/models"
+)
+
+func TestViewQueryKeepsBothQueries(t *testing.T) {
+	tests := []struct {
+		view, extra string
+		item        models.BacklogItem
+		want        bool
+	}{
+		{"status:todo", "tag:api OR tag:web", models.BacklogItem{Status: models.StatusDone, Tags: []string{"web"}}, false},
+		{"status:todo", "tag:api OR tag:web", models.BacklogItem{Status: models.StatusTodo, Tags: []string{"web"}}, true},
+		{"status:todo OR status:done", "tag:api", models.BacklogItem{Status: models.StatusTodo}, false},
+		{"status:todo", "", models.BacklogItem{Status: models.StatusTodo}, true},
+		{"", "tag:api", models.BacklogItem{Tags: []string{"api"}}, true},
+	}
+	for _, tt := range tests {
+		query := viewQuery(tt.view, tt.extra)
+		expr, err := filter.Parse(query)
+		if err != nil {
+			t.Fatalf("viewQuery(%q, %q) = %q: %v", tt.view, tt.extra, query, err)
+		}
+		if got := expr.Match(tt.item); got != tt.want {
+			t.Errorf("%q matching %+v = %v, want %v", query, tt.item, got, tt.want)
+		}
+	}
+}
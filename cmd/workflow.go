@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/vvb/backlog/models"
+	"github.com/vvb/backlog/storage"
+)
+
+var (
+	workflowDone        string
+	workflowTransitions []string
+	wipStrict           bool
+)
+
+var boardWorkflowCmd = &cobra.Command{
+	Use:   "workflow",
+	Short: "Show the current board's workflow",
+	Long: `Show the statuses of the current board, in column order, with the status that
+counts as done and the moves allowed from each status.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workflow, err := boardWorkflow()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println()
+		for i, def := range workflow.Statuses {
+			line := fmt.Sprintf("%d. %-15s %-15s", i+1, def.Status, def.Label())
+			if targets, ok := workflow.Transitions[def.Status]; ok {
+				line += " → " + models.JoinStatuses(targets)
+			}
+			if def.Limit > 0 {
+				line += fmt.Sprintf(" [WIP %d]", def.Limit)
+			}
+			if def.Status == workflow.Done {
+				line += " (done)"
+			}
+			fmt.Println(strings.TrimRight(line, " "))
+		}
+		fmt.Println()
+
+		return nil
+	},
+}
+
+var boardWorkflowSetCmd = &cobra.Command{
+	Use:   "set [status[=Name]]...",
+	Short: "Set the current board's workflow",
+	Long: `Set the statuses of the current board, in column order. Each status may carry
+a display name after '=', e.g. review="In Review". New items start in the
+first status. --done picks the status that counts as completed (the last one
+by default), and each --transition from=to,to limits where items in a status
+may move; statuses without one may move anywhere.
+
+  backlog board workflow set todo="To Do" in-progress="In Progress" review qa blocked done \
+    --transition todo=in-progress,blocked --transition qa=done,in-progress
+
+Statuses still used by items on the board can't be removed. WIP limits of the
+statuses that are kept carry over.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workflow := &models.Workflow{}
+		for _, arg := range args {
+			status, name, _ := strings.Cut(arg, "=")
+			workflow.Statuses = append(workflow.Statuses, models.StatusDef{
+				Status: models.Status(strings.TrimSpace(status)),
+				Name:   strings.TrimSpace(name),
+			})
+		}
+
+		workflow.Done = workflow.Statuses[len(workflow.Statuses)-1].Status
+		if workflowDone != "" {
+			workflow.Done = models.Status(workflowDone)
+		}
+
+		for _, transition := range workflowTransitions {
+			from, to, ok := strings.Cut(transition, "=")
+			if !ok {
+				return fmt.Errorf("invalid transition %q (e.g. todo=in-progress,blocked)", transition)
+			}
+			if workflow.Transitions == nil {
+				workflow.Transitions = map[models.Status][]models.Status{}
+			}
+			targets := []models.Status{}
+			for _, target := range splitTags(to) {
+				targets = append(targets, models.Status(target))
+			}
+			workflow.Transitions[models.Status(strings.TrimSpace(from))] = targets
+		}
+
+		// Keep the WIP limits of statuses that stay
+		current, err := boardWorkflow()
+		if err != nil {
+			return err
+		}
+		for i := range workflow.Statuses {
+			workflow.Statuses[i].Limit = current.Limit(workflow.Statuses[i].Status)
+		}
+		workflow.StrictWIP = current.StrictWIP
+
+		if err := workflow.Validate(); err != nil {
+			return err
+		}
+
+		if err := saveWorkflow(workflow); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Set workflow: %s\n", workflow.StatusList())
+		return nil
+	},
+}
+
+var boardWorkflowResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Go back to the default todo, in-progress, done workflow",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := saveWorkflow(nil); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Set workflow: %s\n", models.DefaultWorkflow().StatusList())
+		return nil
+	},
+}
+
+var boardWIPCmd = &cobra.Command{
+	Use:   "wip [status] [limit]",
+	Short: "Show or set WIP limits",
+	Long: `Show the work-in-progress limits of the current board's statuses, or limit
+how many items a status may hold (0 removes the limit).
+
+Moves that would exceed a limit only print a warning, unless the limits are
+made strict with --strict, in which case they are refused.
+
+  backlog board wip in-progress 4
+  backlog board wip --strict`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 || len(args) > 2 {
+			return fmt.Errorf("give both a status and a limit, or neither")
+		}
+
+		dir, err := currentBoardDir()
+		if err != nil {
+			return err
+		}
+		config, err := storage.LoadBoardConfig(dir)
+		if err != nil {
+			return err
+		}
+		workflow := config.BoardWorkflow()
+
+		if len(args) == 0 && !cmd.Flags().Changed("strict") {
+			fmt.Println()
+			for _, def := range workflow.Statuses {
+				limit := "no limit"
+				if def.Limit > 0 {
+					limit = strconv.Itoa(def.Limit)
+				}
+				fmt.Printf("%-15s %s\n", def.Status, limit)
+			}
+			mode := "warn"
+			if workflow.StrictWIP {
+				mode = "strict"
+			}
+			fmt.Printf("\nMode: %s\n\n", mode)
+			return nil
+		}
+
+		if len(args) == 2 {
+			status := models.Status(args[0])
+			if err := workflow.CheckStatus(status); err != nil {
+				return err
+			}
+			limit, err := strconv.Atoi(args[1])
+			if err != nil || limit < 0 {
+				return fmt.Errorf("invalid limit %q: use a whole number, or 0 for none", args[1])
+			}
+			workflow.Statuses[workflow.Index(status)].Limit = limit
+		}
+		if cmd.Flags().Changed("strict") {
+			workflow.StrictWIP = wipStrict
+		}
+
+		config.Workflow = workflow
+		if err := storage.SaveBoardConfig(dir, config); err != nil {
+			return err
+		}
+
+		if len(args) == 2 {
+			if limit := workflow.Limit(models.Status(args[0])); limit > 0 {
+				fmt.Printf("✓ Limited %s to %d item(s)\n", args[0], limit)
+			} else {
+				fmt.Printf("✓ Removed the WIP limit of %s\n", args[0])
+			}
+		}
+		if cmd.Flags().Changed("strict") {
+			if workflow.StrictWIP {
+				fmt.Println("✓ WIP limits are strict: moves over a limit are refused")
+			} else {
+				fmt.Println("✓ WIP limits only warn")
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	boardWorkflowSetCmd.Flags().StringVar(&workflowDone, "done", "", "Status that counts as done (defaults to the last one)")
+	boardWorkflowSetCmd.Flags().StringArrayVar(&workflowTransitions, "transition", nil, "Allowed moves from a status, as from=to,to (repeatable)")
+
+	boardWorkflowCmd.AddCommand(boardWorkflowSetCmd)
+	boardWorkflowCmd.AddCommand(boardWorkflowResetCmd)
+	boardCmd.AddCommand(boardWorkflowCmd)
+
+	boardWIPCmd.Flags().BoolVar(&wipStrict, "strict", false, "Refuse moves over a limit instead of warning (--strict=false to only warn)")
+	boardCmd.AddCommand(boardWIPCmd)
+}
+
+// saveWorkflow stores the workflow in the current board's config, nil
+// meaning the default workflow. It refuses to drop a status that items
+// on the board are still in. The board stays locked from that check until
+// the config is saved, so no item can move into a dropped status meanwhile.
+func saveWorkflow(workflow *models.Workflow) error {
+	dir, err := currentBoardDir()
+	if err != nil {
+		return err
+	}
+
+	// Create storage
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	config, err := storage.LoadBoardConfig(dir)
+	if err != nil {
+		return err
+	}
+
+	// Load backlog
+	backlog, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	next := workflow
+	if next == nil {
+		next = models.DefaultWorkflow()
+	}
+	inUse := map[models.Status]int{}
+	for _, item := range backlog.Items {
+		if !next.Has(item.Status) {
+			inUse[item.Status]++
+		}
+	}
+	for _, def := range config.BoardWorkflow().Statuses {
+		if n := inUse[def.Status]; n > 0 {
+			return fmt.Errorf("status %q is still used by %d item(s), move them to another status first", def.Status, n)
+		}
+	}
+
+	config.Workflow = workflow
+	return storage.SaveBoardConfig(dir, config)
+}
+
+// checkWIPLimit applies the workflow's WIP limits to moving an item into
+// status. Strict workflows refuse the move with an error; otherwise the
+// move may go ahead and a warning to show is returned instead.
+func checkWIPLimit(workflow *models.Workflow, items []models.BacklogItem, status models.Status) (string, error) {
+	var limitErr *models.WIPLimitError
+	if !errors.As(workflow.CheckLimit(items, status), &limitErr) {
+		return "", nil
+	}
+	if workflow.StrictWIP {
+		return "", fmt.Errorf("%w, and the board's WIP limits are strict", limitErr)
+	}
+	return fmt.Sprintf("%s is now over its WIP limit (%d/%d)", status, limitErr.Count+1, limitErr.Limit), nil
+}
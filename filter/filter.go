@@ -0,0 +1,194 @@
+// Package filter implements the query language used to filter items in
+// list, search and interactive mode, such as
+//
+//	status:todo tag:api due<2025-12-01 -tag:wontfix "exact phrase" priority>=P1
+//
+// Terms are ANDed together; OR and parentheses group alternatives and a
+// leading - negates a term. Parse turns a query into an Expr tree that
+// matches items.
+package filter
+
+import (
+	"strings"
+	"time"
+
+	"github.com/vvb/backlog/models"
+)
+
+// Expr is a node of a parsed query
+type Expr interface {
+	// Match reports whether item satisfies the expression
+	Match(item models.BacklogItem) bool
+}
+
+// And matches items that match all of its expressions. The empty And
+// matches everything.
+type And []Expr
+
+func (a And) Match(item models.BacklogItem) bool {
+	for _, x := range a {
+		if !x.Match(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// Or matches items that match any of its expressions
+type Or []Expr
+
+func (o Or) Match(item models.BacklogItem) bool {
+	for _, x := range o {
+		if x.Match(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// Not matches items that X doesn't match
+type Not struct {
+	X Expr
+}
+
+func (n Not) Match(item models.BacklogItem) bool {
+	return !n.X.Match(item)
+}
+
+// Text matches items whose title, description or tags contain Value,
+// ignoring case. Bare words and quoted phrases parse to Text.
+type Text struct {
+	Value string
+}
+
+func (t Text) Match(item models.BacklogItem) bool {
+	value := strings.ToLower(t.Value)
+	if strings.Contains(strings.ToLower(item.Title), value) {
+		return true
+	}
+	if strings.Contains(strings.ToLower(item.Description), value) {
+		return true
+	}
+	for _, tag := range item.Tags {
+		if strings.Contains(strings.ToLower(tag), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Op is the comparison of a field term
+type Op string
+
+const (
+	OpEq Op = ":"
+	OpLt Op = "<"
+	OpLe Op = "<="
+	OpGt Op = ">"
+	OpGe Op = ">="
+)
+
+// Field matches items by one of their fields, such as status:todo or
+// due<2025-12-01. Values are checked by Parse, which also fills in the
+// parsed form the comparison needs.
+type Field struct {
+	Name   string
+	Op     Op
+	Values []string // alternatives, from a comma-separated value
+
+	dates      []time.Time       // for date fields; the zero time means none
+	priorities []models.Priority // for priority; the empty Priority means none
+}
+
+func (f Field) Match(item models.BacklogItem) bool {
+	for i, value := range f.Values {
+		if f.matchValue(item, i, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchValue compares the field against its i-th value
+func (f Field) matchValue(item models.BacklogItem, i int, value string) bool {
+	switch f.Name {
+	case "status":
+		return strings.EqualFold(string(item.Status), value)
+
+	case "tag":
+		for _, tag := range item.Tags {
+			if strings.EqualFold(tag, value) {
+				return true
+			}
+		}
+		return false
+
+	case "title":
+		return strings.Contains(strings.ToLower(item.Title), strings.ToLower(value))
+
+	case "desc":
+		return strings.Contains(strings.ToLower(item.Description), strings.ToLower(value))
+
+	case "priority":
+		want := f.priorities[i]
+		if f.Op == OpEq || want == "" || item.Priority == "" {
+			return item.Priority == want
+		}
+		// Higher priorities are more urgent, P0 being the highest
+		return compare(urgency(item.Priority), urgency(want), f.Op)
+
+	case "due", "created", "updated":
+		day, ok := itemDate(item, f.Name)
+		want := f.dates[i]
+		if want.IsZero() {
+			return !ok
+		}
+		return ok && compare(day.Unix(), want.Unix(), f.Op)
+	}
+	return false
+}
+
+// urgency orders priorities, P0 being the most urgent
+func urgency(p models.Priority) int {
+	for i, q := range models.Priorities {
+		if p == q {
+			return len(models.Priorities) - i
+		}
+	}
+	return 0
+}
+
+// itemDate returns the day a date field of item falls on
+func itemDate(item models.BacklogItem, field string) (time.Time, bool) {
+	switch field {
+	case "created":
+		return day(item.CreatedAt), true
+	case "updated":
+		return day(item.UpdatedAt), true
+	}
+	if item.DueDate == "" {
+		return time.Time{}, false
+	}
+	due, err := time.Parse("02-01-2006", item.DueDate)
+	return due, err == nil
+}
+
+// day returns midnight UTC of t's local date, the form dates are compared in
+func day(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func compare[T int | int64](a, b T, op Op) bool {
+	switch op {
+	case OpLt:
+		return a < b
+	case OpLe:
+		return a <= b
+	case OpGt:
+		return a > b
+	case OpGe:
+		return a >= b
+	}
+	return a == b
+}
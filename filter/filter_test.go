@@ -0,0 +1,92 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vvb/backlog/models"
+)
+
+func TestMatch(t *testing.T) {
+	created := time.Date(2026, 10, 1, 12, 0, 0, 0, time.Local)
+	item := models.BacklogItem{
+		Title:       "Fix the Login page",
+		Description: "Users can't sign in on mobile",
+		Status:      models.StatusInProgress,
+		Priority:    models.PriorityP1,
+		DueDate:     "15-11-2026",
+		Tags:        []string{"API", "frontend"},
+		CreatedAt:   created,
+		UpdatedAt:   created.AddDate(0, 0, 5),
+	}
+	undated := models.BacklogItem{Title: "Someday", Status: models.StatusTodo}
+
+	tests := []struct {
+		query string
+		item  models.BacklogItem
+		want  bool
+	}{
+		// Words and phrases match title, description and tags, ignoring case
+		{"login", item, true},
+		{"LOGIN", item, true},
+		{"mobile", item, true},
+		{"fronte", item, true},
+		{`"sign in"`, item, true},
+		{`"sign out"`, item, false},
+		{"login missing", item, false},
+
+		{"status:in-progress", item, true},
+		{"status:IN-PROGRESS", item, true},
+		{"status:todo,in-progress", item, true},
+		{"status:todo", item, false},
+		{"tag:api", item, true},
+		{"tag:fronte", item, false},
+		{"tag:docs,frontend", item, true},
+		{`title:"login page"`, item, true},
+		{"desc:mobile", item, true},
+		{"desc:login", item, false},
+
+		{"priority:P1", item, true},
+		{"priority>=P2", item, true},
+		{"priority>P1", item, false},
+		{"priority<=P1", item, true},
+		{"priority<P1", item, false},
+		{"priority:none", undated, true},
+		{"priority>=P3", undated, false},
+
+		// Dates compare by day, in either format
+		{"due:2026-11-15", item, true},
+		{"due:15-11-2026", item, true},
+		{"due<2026-11-15", item, false},
+		{"due<=2026-11-15", item, true},
+		{"due>2026-11-14", item, true},
+		{"due:none", item, false},
+		{"due:none", undated, true},
+		{"due<2026-11-15", undated, false},
+		{"created:2026-10-01", item, true},
+		{"created>=2026-10-02", item, false},
+		{"updated:2026-10-06", item, true},
+
+		// Precedence and negation
+		{"-tag:api", item, false},
+		{"-tag:docs", item, true},
+		{"--tag:api", item, true},
+		{"status:todo OR tag:api", item, true},
+		{"status:todo tag:api OR login", item, true},
+		{"status:todo (tag:api OR login)", item, false},
+		{"-(status:todo OR tag:docs)", item, true},
+		{"-(status:todo OR tag:api)", item, false},
+		{"", item, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			expr, err := Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := expr.Match(tt.item); got != tt.want {
+				t.Errorf("%q matching %q = %v, want %v", tt.query, tt.item.Title, got, tt.want)
+			}
+		})
+	}
+}
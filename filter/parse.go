@@ -0,0 +1,297 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/vvb/backlog/models"
+)
+
+// Fields lists the fields a query can name
+var Fields = []string{"status", "tag", "priority", "due", "created", "updated", "title", "desc"}
+
+// fieldAliases maps alternative field names to the ones in Fields
+var fieldAliases = map[string]string{
+	"tags":        "tag",
+	"description": "desc",
+}
+
+// Parse parses a query. The empty query matches every item.
+func Parse(query string) (Expr, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q in query", tok.text)
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokenTerm tokenKind = iota
+	tokenPhrase
+	tokenNot
+	tokenOpen
+	tokenClose
+	tokenOr
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// lex splits a query into tokens. Quotes group text with spaces into one
+// term: a whole quoted term is a phrase, while in title:"a b" only the
+// value is quoted.
+func lex(query string) ([]token, error) {
+	var tokens []token
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "("})
+			i++
+			continue
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")"})
+			i++
+			continue
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]):
+			tokens = append(tokens, token{kind: tokenNot, text: "-"})
+			i++
+			continue
+		}
+
+		kind := tokenTerm
+		if r == '"' {
+			kind = tokenPhrase
+		}
+		var text strings.Builder
+		quoted := false
+		for ; i < len(runes); i++ {
+			r := runes[i]
+			if r == '"' {
+				quoted = !quoted
+				continue
+			}
+			if !quoted && (unicode.IsSpace(r) || r == '(' || r == ')') {
+				break
+			}
+			text.WriteRune(r)
+		}
+		if quoted {
+			return nil, fmt.Errorf("unterminated quote in query")
+		}
+		if kind == tokenTerm && text.String() == "OR" {
+			kind = tokenOr
+		}
+		if kind == tokenTerm && text.String() == "NOT" {
+			kind = tokenNot
+		}
+		tokens = append(tokens, token{kind: kind, text: text.String()})
+	}
+	return tokens, nil
+}
+
+// parser is a recursive descent parser over the grammar
+//
+//	or   = and { "OR" and }
+//	and  = { not }
+//	not  = ( "-" | "NOT" ) not | "(" or ")" | term
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	var or Or
+	for {
+		and, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		tok, ok := p.peek()
+		more := ok && tok.kind == tokenOr
+		if terms, isAnd := and.(And); isAnd && len(terms) == 0 && (more || len(or) > 0) {
+			return nil, fmt.Errorf("OR needs a term on both sides in query")
+		}
+		or = append(or, and)
+
+		if !more {
+			break
+		}
+		p.pos++
+	}
+	if len(or) == 1 {
+		return or[0], nil
+	}
+	return or, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	var and And
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokenOr || tok.kind == tokenClose {
+			break
+		}
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, expr)
+	}
+	if len(and) == 1 {
+		return and[0], nil
+	}
+	return and, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	tok, _ := p.peek()
+	p.pos++
+
+	switch tok.kind {
+	case tokenNot:
+		if next, ok := p.peek(); !ok || next.kind == tokenOr || next.kind == tokenClose {
+			return nil, fmt.Errorf("nothing to negate after '%s' in query", tok.text)
+		}
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return Not{X: x}, nil
+
+	case tokenOpen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if next, ok := p.peek(); !ok || next.kind != tokenClose {
+			return nil, fmt.Errorf("missing ')' in query")
+		}
+		p.pos++
+		if terms, isAnd := x.(And); isAnd && len(terms) == 0 {
+			return nil, fmt.Errorf("empty parentheses in query")
+		}
+		return x, nil
+
+	case tokenPhrase:
+		return Text{Value: tok.text}, nil
+	}
+	return parseTerm(tok.text)
+}
+
+// parseTerm parses a bare word or a field term such as priority>=P1. A
+// word before ':' or a comparison that doesn't name a field is plain
+// text, so "re:" or "http://example.com" are searched for as written.
+func parseTerm(term string) (Expr, error) {
+	end := strings.IndexAny(term, ":<>=")
+	if end <= 0 || !isWord(term[:end]) {
+		return Text{Value: term}, nil
+	}
+
+	name := strings.ToLower(term[:end])
+	if alias, ok := fieldAliases[name]; ok {
+		name = alias
+	}
+	known := false
+	for _, field := range Fields {
+		known = known || field == name
+	}
+	if !known {
+		return Text{Value: term}, nil
+	}
+
+	rest := term[end:]
+	op := OpEq
+	for _, candidate := range []Op{OpLe, OpGe, OpLt, OpGt, OpEq, "="} {
+		if strings.HasPrefix(rest, string(candidate)) {
+			op = candidate
+			rest = rest[len(candidate):]
+			break
+		}
+	}
+	if op == "=" {
+		op = OpEq
+	}
+	if rest == "" {
+		return nil, fmt.Errorf("missing value for %s in query", name)
+	}
+
+	f := Field{Name: name, Op: op, Values: strings.Split(rest, ",")}
+	switch name {
+	case "priority":
+		for _, value := range f.Values {
+			priority, err := models.ParsePriority(value)
+			if err != nil {
+				return nil, err
+			}
+			f.priorities = append(f.priorities, priority)
+		}
+
+	case "due", "created", "updated":
+		for _, value := range f.Values {
+			date, err := parseDate(value)
+			if err != nil {
+				return nil, err
+			}
+			f.dates = append(f.dates, date)
+		}
+
+	default:
+		if op != OpEq {
+			return nil, fmt.Errorf("%s can't be compared with %s in query, use %s:value", name, op, name)
+		}
+	}
+	return f, nil
+}
+
+// parseDate parses a date in a query: YYYY-MM-DD, DD-MM-YYYY, today, or
+// none for no date
+func parseDate(value string) (time.Time, error) {
+	switch strings.ToLower(value) {
+	case "none":
+		return time.Time{}, nil
+	case "today":
+		return day(time.Now()), nil
+	}
+	for _, layout := range []string{"2006-01-02", "02-01-2006"} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q in query. Use YYYY-MM-DD, DD-MM-YYYY, today or none", value)
+}
+
+func isWord(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// describe renders an expression in a compact form for comparing trees
+func describe(expr Expr) string {
+	switch x := expr.(type) {
+	case And:
+		parts := make([]string, len(x))
+		for i, e := range x {
+			parts[i] = describe(e)
+		}
+		return "and(" + strings.Join(parts, " ") + ")"
+	case Or:
+		parts := make([]string, len(x))
+		for i, e := range x {
+			parts[i] = describe(e)
+		}
+		return "or(" + strings.Join(parts, " ") + ")"
+	case Not:
+		return "not(" + describe(x.X) + ")"
+	case Text:
+		return fmt.Sprintf("%q", x.Value)
+	case Field:
+		return x.Name + string(x.Op) + strings.Join(x.Values, ",")
+	}
+	return fmt.Sprintf("%#v", expr)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "and()"},
+		{"login", `"login"`},
+		{"login page", `and("login" "page")`},
+		{`"sign in" page`, `and("sign in" "page")`},
+		{`title:"sign in"`, "title:sign in"},
+		{"status:todo,done", "status:todo,done"},
+		{"Tags:api", "tag:api"},
+		{"description:auth", "desc:auth"},
+		{"status=todo", "status:todo"},
+		{"priority>=P1", "priority>=P1"},
+		{"due<2026-12-01", "due<2026-12-01"},
+		{"due:none", "due:none"},
+		{"updated>=today", "updated>=today"},
+		{"created<=01-12-2026", "created<=01-12-2026"},
+		{"-", `"-"`},
+		{"a-b", `"a-b"`},
+		{"- a", `and("-" "a")`},
+
+		// Words that aren't field names are text, colon and all
+		{"re: meeting", `and("re:" "meeting")`},
+		{"http://example.com", `"http://example.com"`},
+		{"colour:red", `"colour:red"`},
+		{"ratio>=2", `"ratio>=2"`},
+
+		// AND binds tighter than OR, and parentheses group
+		{"a b OR c", `or(and("a" "b") "c")`},
+		{"a OR b c", `or("a" and("b" "c"))`},
+		{"a (b OR c)", `and("a" or("b" "c"))`},
+		{"(a OR b) (c OR d)", `and(or("a" "b") or("c" "d"))`},
+		{"a or b", `and("a" "or" "b")`},
+
+		// NOT applies to the term or group right after it
+		{"-tag:wontfix", "not(tag:wontfix)"},
+		{"-a b", `and(not("a") "b")`},
+		{"-(a OR b)", `not(or("a" "b"))`},
+		{"--a", `not(not("a"))`},
+		{`-"a b"`, `not("a b")`},
+		{"NOT tag:wontfix", "not(tag:wontfix)"},
+		{"a NOT (b OR c)", `and("a" not(or("b" "c")))`},
+		{"not a", `and("not" "a")`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			expr, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.query, err)
+			}
+			if got := describe(expr); got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{"()", "empty parentheses"},
+		{"a () b", "empty parentheses"},
+		{"-()", "empty parentheses"},
+		{"(a", "missing ')'"},
+		{"a)", `unexpected ")"`},
+		{"OR a", "OR needs a term on both sides"},
+		{"a OR", "OR needs a term on both sides"},
+		{"a OR OR b", "OR needs a term on both sides"},
+		{"-OR a", "nothing to negate"},
+		{"a -)", "nothing to negate"},
+		{`"sign in`, "unterminated quote"},
+		{`title:"sign`, "unterminated quote"},
+		{"NOT", "nothing to negate after 'NOT'"},
+		{"a NOT OR b", "nothing to negate"},
+		{"status:", "missing value for status"},
+		{"status<todo", "can't be compared"},
+		{"tag>=api", "can't be compared"},
+		{"priority:P9", "P9"},
+		{"due<tomorrow", `invalid date "tomorrow"`},
+		{"due<2026-13-01", "invalid date"},
+		{"created:31-02-2026", "invalid date"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			expr, err := Parse(tt.query)
+			if err == nil {
+				t.Fatalf("Parse(%q) = %s, want an error", tt.query, describe(expr))
+			}
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Parse(%q): %v, want an error mentioning %q", tt.query, err, tt.err)
+			}
+		})
+	}
+}
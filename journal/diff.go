@@ -0,0 +1,232 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vvb/backlog/models"
+)
+
+// untrackedFields change as a side effect of other changes and aren't
+// recorded on their own
+var untrackedFields = map[string]bool{
+	"updated_at": true,
+	"history":    true,
+}
+
+// quietFields are recorded so they can be undone, but aren't worth noting
+// in the item history. Notes and time entries carry their own timestamps.
+var quietFields = map[string]bool{
+	"rank":          true,
+	"notes":         true,
+	"time_log":      true,
+	"timer_started": true,
+}
+
+// itemFields returns an item's fields keyed by their JSON names
+func itemFields(item models.BacklogItem) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// diffItem returns field-changed and moved events turning old into new
+func diffItem(old, new models.BacklogItem) ([]Event, error) {
+	oldFields, err := itemFields(old)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := itemFields(new)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for name := range oldFields {
+		names[name] = true
+	}
+	for name := range newFields {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var events []Event
+	for _, name := range sorted {
+		if untrackedFields[name] || bytes.Equal(oldFields[name], newFields[name]) {
+			continue
+		}
+
+		eventType := EventFieldChanged
+		if name == "status" {
+			eventType = EventMoved
+		}
+		events = append(events, Event{
+			Type:   eventType,
+			ItemID: new.ID,
+			Title:  new.Title,
+			Field:  name,
+			Old:    oldFields[name],
+			New:    newFields[name],
+		})
+	}
+	return events, nil
+}
+
+// stampHistory timestamps events and appends the changes they describe
+// to the history of the affected items. Created items start their history
+// with their initial status.
+func stampHistory(items []models.BacklogItem, events []Event) {
+	now := time.Now()
+
+	byID := map[string][]int{}
+	for i := range events {
+		events[i].Time = now
+		byID[events[i].ItemID] = append(byID[events[i].ItemID], i)
+	}
+
+	for i := range items {
+		item := &items[i]
+		for _, j := range byID[item.ID] {
+			event := &events[j]
+			switch {
+			case event.Type == EventCreated:
+				item.History = append(item.History, models.Change{At: now, Field: "status", New: string(item.Status)})
+				snapshot := *item
+				event.Item = &snapshot
+			case event.Field != "" && !quietFields[event.Field]:
+				item.History = append(item.History, models.Change{
+					At:    now,
+					Field: event.Field,
+					Old:   displayValue(event.Field, event.Old),
+					New:   displayValue(event.Field, event.New),
+				})
+			}
+		}
+	}
+}
+
+// displayValue renders a JSON-encoded field value for the item history.
+// Checklists are summarized by their progress.
+func displayValue(field string, raw json.RawMessage) string {
+	if raw == nil || string(raw) == "null" {
+		return ""
+	}
+
+	if field == "checklist" {
+		var checklist []models.ChecklistEntry
+		if err := json.Unmarshal(raw, &checklist); err == nil {
+			return models.ChecklistSummary(checklist)
+		}
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return strings.Join(list, ", ")
+	}
+
+	return string(raw)
+}
+
+// setField sets one field of item from its JSON encoding. A nil value
+// clears the field.
+func setField(item *models.BacklogItem, name string, value json.RawMessage) error {
+	fields, err := itemFields(*item)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		delete(fields, name)
+	} else {
+		fields[name] = value
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	var updated models.BacklogItem
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return fmt.Errorf("failed to set %s: %w", name, err)
+	}
+	*item = updated
+	return nil
+}
+
+// cloneBacklog deep-copies a backlog so later in-place edits by the
+// caller don't leak into a recorded snapshot
+func cloneBacklog(backlog *models.Backlog) (*models.Backlog, error) {
+	data, err := json.Marshal(backlog)
+	if err != nil {
+		return nil, err
+	}
+	var clone models.Backlog
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
+// Describe returns a short human-readable summary of the event
+func (e Event) Describe() string {
+	switch e.Type {
+	case EventCreated:
+		return fmt.Sprintf("created '%s'", e.Title)
+	case EventDeleted:
+		return fmt.Sprintf("deleted '%s'", e.Title)
+	case EventArchived:
+		return fmt.Sprintf("archived '%s'", e.Title)
+	case EventRestored:
+		return fmt.Sprintf("restored '%s' from the trash", e.Title)
+	case EventUnarchived:
+		return fmt.Sprintf("unarchived '%s'", e.Title)
+	case EventMoved:
+		return fmt.Sprintf("moved '%s' from %s to %s", e.Title, rawString(e.Old), rawString(e.New))
+	case EventFieldChanged:
+		return fmt.Sprintf("changed %s of '%s'", e.Field, e.Title)
+	default:
+		return string(e.Type)
+	}
+}
+
+// Describe summarizes the transaction by its first event
+func (tx Tx) Describe() string {
+	if len(tx.Events) == 0 {
+		return "empty change"
+	}
+	summary := tx.Events[0].Describe()
+	if n := len(tx.Events) - 1; n > 0 {
+		summary += fmt.Sprintf(" (+%d more change(s))", n)
+	}
+	return summary
+}
+
+// rawString renders a JSON-encoded value for messages
+func rawString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	if raw == nil {
+		return "(none)"
+	}
+	return string(raw)
+}
@@ -0,0 +1,194 @@
+// Package journal records every change made to a board as an append-only
+// log of events, and uses that log to undo and redo changes.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vvb/backlog/models"
+)
+
+const journalFile = "journal.jsonl"
+
+// EventType identifies the kind of change an event records
+type EventType string
+
+const (
+	EventCreated      EventType = "created"
+	EventFieldChanged EventType = "field-changed"
+	EventMoved        EventType = "moved"
+	EventDeleted      EventType = "deleted"
+	EventArchived     EventType = "archived"
+	EventRestored     EventType = "restored"
+	EventUnarchived   EventType = "unarchived"
+
+	// EventUndo and EventRedo mark a transaction as undone or redone.
+	// They don't change items themselves.
+	EventUndo EventType = "undo"
+	EventRedo EventType = "redo"
+)
+
+// Event is a single recorded change
+type Event struct {
+	// Tx groups the events of one command, which are undone together
+	Tx     int64     `json:"tx"`
+	Time   time.Time `json:"time"`
+	Type   EventType `json:"type"`
+	ItemID string    `json:"item_id,omitempty"`
+	Title  string    `json:"title,omitempty"`
+
+	// Field, Old and New describe field-changed and moved events. Old
+	// and New hold the JSON encoding of the field's value.
+	Field string          `json:"field,omitempty"`
+	Old   json.RawMessage `json:"old,omitempty"`
+	New   json.RawMessage `json:"new,omitempty"`
+
+	// Item is the full item for created, deleted, archived, restored and
+	// unarchived events, as it was right before deletion or archiving,
+	// right after creation, or in the trash or archive right before it was
+	// brought back
+	Item *models.BacklogItem `json:"item,omitempty"`
+
+	// Target is the transaction an undo or redo event refers to
+	Target int64 `json:"target,omitempty"`
+}
+
+// Tx is a group of events recorded by one command
+type Tx struct {
+	ID     int64
+	Events []Event
+}
+
+// ErrNothingToUndo is returned by Undo when every change has been undone
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// ErrNothingToRedo is returned by Redo when no undone change is left
+var ErrNothingToRedo = errors.New("nothing to redo")
+
+// ErrNotLoaded is returned by a Recorder asked to save a collection that
+// wasn't loaded through it first
+var ErrNotLoaded = errors.New("saved without loading")
+
+// Journal is the append-only event log of one board
+type Journal struct {
+	path string
+}
+
+// Open returns the journal of the board stored in dir
+func Open(dir string) *Journal {
+	return &Journal{path: filepath.Join(dir, journalFile)}
+}
+
+// Append writes events to the end of the journal and syncs it to disk
+func (j *Journal) Append(events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal journal event: %w", err)
+		}
+		w.Write(data)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	return nil
+}
+
+// Events reads the whole journal in order
+func (j *Journal) Events() ([]Event, error) {
+	f, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("failed to parse journal line %d: %w", line, err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	return events, nil
+}
+
+// stacks replays the journal and returns the transactions that can be
+// undone and redone, most recent last
+func (j *Journal) stacks() (undo, redo []Tx, err error) {
+	events, err := j.Events()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := 0; i < len(events); i++ {
+		event := events[i]
+		switch event.Type {
+		case EventUndo:
+			if n := len(undo); n > 0 && undo[n-1].ID == event.Target {
+				redo = append(redo, undo[n-1])
+				undo = undo[:n-1]
+			}
+		case EventRedo:
+			if n := len(redo); n > 0 && redo[n-1].ID == event.Target {
+				undo = append(undo, redo[n-1])
+				redo = redo[:n-1]
+			}
+		default:
+			// Events of one transaction are always appended together
+			tx := Tx{ID: event.Tx, Events: []Event{event}}
+			for i+1 < len(events) && events[i+1].Tx == event.Tx && !isMarker(events[i+1].Type) {
+				i++
+				tx.Events = append(tx.Events, events[i])
+			}
+			undo = append(undo, tx)
+			// A new change makes everything undone unreachable
+			redo = nil
+		}
+	}
+
+	return undo, redo, nil
+}
+
+func isMarker(t EventType) bool {
+	return t == EventUndo || t == EventRedo
+}
+
+// newTxID returns a transaction ID that sorts after all earlier ones
+func newTxID() int64 {
+	return time.Now().UnixNano()
+}
@@ -0,0 +1,649 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vvb/backlog/models"
+	"github.com/vvb/backlog/storage"
+)
+
+// Recorder is a storage.Store that writes an event to the journal for
+// every change saved through it, and appends field-level changes to the
+// history of the item they affect. Changes made while the lock is held form
+// one transaction, so a whole command is undone at once.
+//
+// Archiving must save the archive before removing the items from the
+// active backlog; the removal is then recorded as archived, not deleted.
+// Restoring from the trash or the archive must save the backlog before
+// removing the items from there; the addition is then recorded as restored
+// or unarchived, not created.
+type Recorder struct {
+	store   storage.Store
+	journal *Journal
+
+	mu       sync.Mutex
+	depth    int
+	tx       int64
+	archived map[string]bool // IDs added to the archive in this transaction
+	backlog  *models.Backlog // active items as last loaded or saved
+	archive  *models.Backlog // archived items as last loaded or saved
+	trash    *models.Backlog // deleted items as last loaded or saved
+}
+
+var (
+	_ storage.Store   = (*Recorder)(nil)
+	_ storage.Querier = (*Recorder)(nil)
+)
+
+// Wrap returns a Recorder that journals changes to store in the board
+// directory dir
+func Wrap(store storage.Store, dir string) *Recorder {
+	return &Recorder{
+		store:    store,
+		journal:  Open(dir),
+		archived: map[string]bool{},
+	}
+}
+
+// Journal returns the journal the recorder writes to
+func (r *Recorder) Journal() *Journal {
+	return r.journal
+}
+
+// Lock takes the store lock and starts a transaction that lasts until the
+// outermost unlock
+func (r *Recorder) Lock() (func(), error) {
+	unlock, err := r.store.Lock()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	if r.depth == 0 {
+		r.tx = newTxID()
+	}
+	r.depth++
+	r.mu.Unlock()
+
+	return func() {
+		r.mu.Lock()
+		r.depth--
+		if r.depth == 0 {
+			r.tx = 0
+			r.archived = map[string]bool{}
+		}
+		r.mu.Unlock()
+		unlock()
+	}, nil
+}
+
+// Load reads the active backlog and remembers it as the base for the
+// next Save
+func (r *Recorder) Load() (*models.Backlog, error) {
+	backlog, err := r.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.remember(&r.backlog, backlog); err != nil {
+		return nil, err
+	}
+	return backlog, nil
+}
+
+// Save writes the active backlog and journals how it differs from the
+// last loaded or saved state
+func (r *Recorder) Save(backlog *models.Backlog) error {
+	unlock, err := r.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	before, err := r.base(&r.backlog, "backlog")
+	if err != nil {
+		return err
+	}
+
+	// Number new items first so their creation is recorded with it
+	backlog.AssignNumbers()
+
+	events, err := r.diffBacklogs(before, backlog)
+	if err != nil {
+		return err
+	}
+	stampHistory(backlog.Items, events)
+
+	if err := r.store.Save(backlog); err != nil {
+		return err
+	}
+	if err := r.remember(&r.backlog, backlog); err != nil {
+		return err
+	}
+
+	return r.record(events)
+}
+
+// LoadArchive reads the archived items
+func (r *Recorder) LoadArchive() (*models.Backlog, error) {
+	archive, err := r.store.LoadArchive()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.remember(&r.archive, archive); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// SaveArchive writes the archived items. Newly archived items are
+// journaled when the same transaction removes them from the backlog.
+func (r *Recorder) SaveArchive(archive *models.Backlog) error {
+	unlock, err := r.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	before, err := r.base(&r.archive, "archive")
+	if err != nil {
+		return err
+	}
+
+	if err := r.store.SaveArchive(archive); err != nil {
+		return err
+	}
+
+	existing := map[string]bool{}
+	for _, item := range before.Items {
+		existing[item.ID] = true
+	}
+	r.mu.Lock()
+	for _, item := range archive.Items {
+		if !existing[item.ID] {
+			r.archived[item.ID] = true
+		}
+	}
+	r.mu.Unlock()
+
+	return r.remember(&r.archive, archive)
+}
+
+// LoadTrash reads the deleted items
+func (r *Recorder) LoadTrash() (*models.Backlog, error) {
+	trash, err := r.store.LoadTrash()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.remember(&r.trash, trash); err != nil {
+		return nil, err
+	}
+	return trash, nil
+}
+
+// SaveTrash writes the deleted items. Moving items to the trash is
+// journaled when the same transaction removes them from the backlog;
+// emptying the trash is permanent and not journaled.
+func (r *Recorder) SaveTrash(trash *models.Backlog) error {
+	if err := r.store.SaveTrash(trash); err != nil {
+		return err
+	}
+	return r.remember(&r.trash, trash)
+}
+
+// Get returns the active item with the given ID
+func (r *Recorder) Get(id string) (*models.BacklogItem, error) {
+	return r.store.Get(id)
+}
+
+// Put inserts or replaces an item and journals the change
+func (r *Recorder) Put(item models.BacklogItem) error {
+	unlock, err := r.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var events []Event
+	before, err := r.store.Get(item.ID)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		created := item
+		events = []Event{{Type: EventCreated, ItemID: item.ID, Title: item.Title, Item: &created}}
+	case err != nil:
+		return err
+	default:
+		if events, err = diffItem(*before, item); err != nil {
+			return err
+		}
+	}
+
+	items := []models.BacklogItem{item}
+	stampHistory(items, events)
+	item = items[0]
+
+	if err := r.store.Put(item); err != nil {
+		return err
+	}
+
+	// Record the number the backend gave a new item
+	if item.Number == 0 {
+		stored, err := r.store.Get(item.ID)
+		if err != nil {
+			return err
+		}
+		item = *stored
+		if len(events) > 0 && events[0].Type == EventCreated {
+			created := item
+			events[0].Item = &created
+		}
+	}
+
+	r.mu.Lock()
+	if r.backlog != nil {
+		if i := indexOf(r.backlog, item.ID); i >= 0 {
+			r.backlog.Items[i] = item
+		} else {
+			r.backlog.Items = append(r.backlog.Items, item)
+		}
+	}
+	r.mu.Unlock()
+
+	return r.record(events)
+}
+
+// Delete removes an item and journals its last state
+func (r *Recorder) Delete(id string) error {
+	unlock, err := r.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	before, err := r.store.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.store.Delete(id); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	if r.backlog != nil {
+		if i := indexOf(r.backlog, id); i >= 0 {
+			r.backlog.Items = append(r.backlog.Items[:i], r.backlog.Items[i+1:]...)
+		}
+	}
+	r.mu.Unlock()
+
+	return r.record([]Event{{Type: EventDeleted, ItemID: id, Title: before.Title, Item: before}})
+}
+
+// Query passes queries through to the wrapped backend
+func (r *Recorder) Query(q storage.Query) ([]models.BacklogItem, error) {
+	return storage.Find(r.store, q)
+}
+
+// Undo reverts the most recent transaction that hasn't been undone
+func (r *Recorder) Undo() (*Tx, error) {
+	return r.replay(false)
+}
+
+// Redo re-applies the most recently undone transaction
+func (r *Recorder) Redo() (*Tx, error) {
+	return r.replay(true)
+}
+
+func (r *Recorder) replay(redo bool) (*Tx, error) {
+	unlock, err := r.store.Lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	undoStack, redoStack, err := r.journal.stacks()
+	if err != nil {
+		return nil, err
+	}
+
+	var tx Tx
+	marker := Event{Type: EventUndo}
+	if redo {
+		if len(redoStack) == 0 {
+			return nil, ErrNothingToRedo
+		}
+		tx = redoStack[len(redoStack)-1]
+		marker.Type = EventRedo
+	} else {
+		if len(undoStack) == 0 {
+			return nil, ErrNothingToUndo
+		}
+		tx = undoStack[len(undoStack)-1]
+	}
+
+	backlog, err := r.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	archive, err := r.store.LoadArchive()
+	if err != nil {
+		return nil, err
+	}
+	trash, err := r.store.LoadTrash()
+	if err != nil {
+		return nil, err
+	}
+
+	state := &boardState{backlog: backlog, archive: archive, trash: trash}
+	touchesArchive, touchesTrash := false, false
+	sizes := [3]int{len(backlog.Items), len(archive.Items), len(trash.Items)}
+	if redo {
+		for _, event := range tx.Events {
+			touchesArchive = touchesArchive || event.Type == EventArchived || event.Type == EventUnarchived
+			touchesTrash = touchesTrash || event.Type == EventDeleted || event.Type == EventRestored
+			if err := apply(event, state); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		for i := len(tx.Events) - 1; i >= 0; i-- {
+			event := tx.Events[i]
+			touchesArchive = touchesArchive || event.Type == EventArchived || event.Type == EventUnarchived
+			touchesTrash = touchesTrash || event.Type == EventDeleted || event.Type == EventRestored
+			if err := revert(event, state); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Write the sides that gain items first, so a crash in between
+	// duplicates an item rather than losing it
+	saves := []struct {
+		touched bool
+		gains   bool
+		save    func() error
+	}{
+		{true, len(backlog.Items) > sizes[0], func() error { return r.store.Save(backlog) }},
+		{touchesArchive, len(archive.Items) > sizes[1], func() error { return r.store.SaveArchive(archive) }},
+		{touchesTrash, len(trash.Items) > sizes[2], func() error { return r.store.SaveTrash(trash) }},
+	}
+	for _, gains := range []bool{true, false} {
+		for _, s := range saves {
+			if s.touched && s.gains == gains {
+				if err := s.save(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	marker.Tx = newTxID()
+	marker.Time = time.Now()
+	marker.Target = tx.ID
+	if err := r.journal.Append([]Event{marker}); err != nil {
+		return nil, err
+	}
+
+	if err := r.remember(&r.backlog, backlog); err != nil {
+		return nil, err
+	}
+	if err := r.remember(&r.archive, archive); err != nil {
+		return nil, err
+	}
+	if err := r.remember(&r.trash, trash); err != nil {
+		return nil, err
+	}
+
+	return &tx, nil
+}
+
+// diffBacklogs compares two states of the active backlog
+func (r *Recorder) diffBacklogs(before, after *models.Backlog) ([]Event, error) {
+	old := make(map[string]models.BacklogItem, len(before.Items))
+	for _, item := range before.Items {
+		old[item.ID] = item
+	}
+	current := make(map[string]bool, len(after.Items))
+
+	r.mu.Lock()
+	trashed := itemsByID(r.trash)
+	archived := itemsByID(r.archive)
+	r.mu.Unlock()
+
+	var events []Event
+	for _, item := range after.Items {
+		current[item.ID] = true
+
+		prev, ok := old[item.ID]
+		if !ok {
+			if deleted, ok := trashed[item.ID]; ok {
+				events = append(events, Event{Type: EventRestored, ItemID: item.ID, Title: item.Title, Item: &deleted})
+				continue
+			}
+			if done, ok := archived[item.ID]; ok {
+				events = append(events, Event{Type: EventUnarchived, ItemID: item.ID, Title: item.Title, Item: &done})
+				continue
+			}
+			created := item
+			events = append(events, Event{Type: EventCreated, ItemID: item.ID, Title: item.Title, Item: &created})
+			continue
+		}
+
+		changes, err := diffItem(prev, item)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, changes...)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, item := range before.Items {
+		if current[item.ID] {
+			continue
+		}
+		removed := item
+		eventType := EventDeleted
+		if r.archived[item.ID] {
+			eventType = EventArchived
+		}
+		events = append(events, Event{Type: eventType, ItemID: item.ID, Title: item.Title, Item: &removed})
+	}
+
+	return events, nil
+}
+
+// record stamps events with the current transaction and appends them
+func (r *Recorder) record(events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	tx := r.tx
+	r.mu.Unlock()
+	if tx == 0 {
+		tx = newTxID()
+	}
+
+	now := time.Now()
+	for i := range events {
+		events[i].Tx = tx
+		if events[i].Time.IsZero() {
+			events[i].Time = now
+		}
+	}
+	return r.journal.Append(events)
+}
+
+// base returns the remembered state a save is compared against. Saving
+// what wasn't loaded through the recorder first is refused: loading it
+// only now would hide changes made since the caller read it, from the
+// journal and from the store's conflict check.
+func (r *Recorder) base(slot **models.Backlog, what string) (*models.Backlog, error) {
+	r.mu.Lock()
+	snapshot := *slot
+	r.mu.Unlock()
+	if snapshot == nil {
+		return nil, fmt.Errorf("%w: the %s must be loaded before it is saved", ErrNotLoaded, what)
+	}
+	return snapshot, nil
+}
+
+// remember stores a private copy of backlog in slot
+func (r *Recorder) remember(slot **models.Backlog, backlog *models.Backlog) error {
+	clone, err := cloneBacklog(backlog)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	*slot = clone
+	r.mu.Unlock()
+	return nil
+}
+
+// boardState holds every collection of a board while a transaction is
+// replayed
+type boardState struct {
+	backlog *models.Backlog
+	archive *models.Backlog
+	trash   *models.Backlog
+}
+
+// revert undoes a single event
+func revert(event Event, state *boardState) error {
+	switch event.Type {
+	case EventCreated:
+		removeItem(state.backlog, event.ItemID)
+	case EventDeleted:
+		removeItem(state.trash, event.ItemID)
+		if event.Item != nil && indexOf(state.backlog, event.ItemID) < 0 {
+			state.backlog.Items = append(state.backlog.Items, *event.Item)
+		}
+	case EventArchived:
+		removeItem(state.archive, event.ItemID)
+		if event.Item != nil && indexOf(state.backlog, event.ItemID) < 0 {
+			state.backlog.Items = append(state.backlog.Items, *event.Item)
+		}
+	case EventRestored:
+		removeItem(state.backlog, event.ItemID)
+		if event.Item != nil && indexOf(state.trash, event.ItemID) < 0 {
+			state.trash.Items = append(state.trash.Items, *event.Item)
+		}
+	case EventUnarchived:
+		removeItem(state.backlog, event.ItemID)
+		if event.Item != nil && indexOf(state.archive, event.ItemID) < 0 {
+			state.archive.Items = append(state.archive.Items, *event.Item)
+		}
+	case EventFieldChanged, EventMoved:
+		return setItemField(state, event.ItemID, event.Field, event.Old)
+	}
+	return nil
+}
+
+// apply re-applies a single event
+func apply(event Event, state *boardState) error {
+	switch event.Type {
+	case EventCreated:
+		if event.Item != nil && indexOf(state.backlog, event.ItemID) < 0 {
+			state.backlog.Items = append(state.backlog.Items, *event.Item)
+		}
+	case EventDeleted:
+		if i := indexOf(state.backlog, event.ItemID); i >= 0 {
+			deleted := state.backlog.Items[i]
+			now := time.Now()
+			deleted.DeletedAt = &now
+			removeItem(state.backlog, event.ItemID)
+			if indexOf(state.trash, event.ItemID) < 0 {
+				state.trash.Items = append(state.trash.Items, deleted)
+			}
+		}
+	case EventArchived:
+		removeItem(state.backlog, event.ItemID)
+		if event.Item != nil && indexOf(state.archive, event.ItemID) < 0 {
+			state.archive.Items = append(state.archive.Items, *event.Item)
+		}
+	case EventRestored:
+		if i := indexOf(state.trash, event.ItemID); i >= 0 {
+			restored := state.trash.Items[i]
+			restored.DeletedAt = nil
+			removeItem(state.trash, event.ItemID)
+			if indexOf(state.backlog, event.ItemID) < 0 {
+				state.backlog.Items = append(state.backlog.Items, restored)
+			}
+		}
+	case EventUnarchived:
+		if i := indexOf(state.archive, event.ItemID); i >= 0 {
+			unarchived := state.archive.Items[i]
+			now := time.Now()
+			unarchived.UnarchivedAt = &now
+			removeItem(state.archive, event.ItemID)
+			if indexOf(state.backlog, event.ItemID) < 0 {
+				state.backlog.Items = append(state.backlog.Items, unarchived)
+			}
+		}
+	case EventFieldChanged, EventMoved:
+		return setItemField(state, event.ItemID, event.Field, event.New)
+	}
+	return nil
+}
+
+// setItemField sets a field on the item wherever it lives now, noting the
+// change in its history. Items that no longer exist are skipped.
+func setItemField(state *boardState, id, field string, value json.RawMessage) error {
+	for _, b := range []*models.Backlog{state.backlog, state.archive, state.trash} {
+		if i := indexOf(b, id); i >= 0 {
+			item := &b.Items[i]
+			fields, err := itemFields(*item)
+			if err != nil {
+				return err
+			}
+			if err := setField(item, field, value); err != nil {
+				return err
+			}
+
+			now := time.Now()
+			item.UpdatedAt = now
+			if !quietFields[field] {
+				item.History = append(item.History, models.Change{
+					At:    now,
+					Field: field,
+					Old:   displayValue(field, fields[field]),
+					New:   displayValue(field, value),
+				})
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// itemsByID indexes a remembered state, which may not be loaded yet
+func itemsByID(backlog *models.Backlog) map[string]models.BacklogItem {
+	items := map[string]models.BacklogItem{}
+	if backlog != nil {
+		for _, item := range backlog.Items {
+			items[item.ID] = item
+		}
+	}
+	return items
+}
+
+func indexOf(backlog *models.Backlog, id string) int {
+	for i := range backlog.Items {
+		if backlog.Items[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func removeItem(backlog *models.Backlog, id string) {
+	if i := indexOf(backlog, id); i >= 0 {
+		backlog.Items = append(backlog.Items[:i], backlog.Items[i+1:]...)
+	}
+}
@@ -0,0 +1,292 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/vvb/backlog/models"
+	"github.com/vvb/backlog/storage"
+)
+
+func TestSaveWithoutLoadIsRefused(t *testing.T) {
+	dir := t.TempDir()
+	store, err := storage.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := models.BacklogItem{ID: "a", Title: "Existing", Status: models.StatusTodo, CreatedAt: time.Now()}
+	if err := store.Save(&models.Backlog{Items: []models.BacklogItem{item}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving through a recorder that never loaded the backlog would
+	// silently replace the item
+	r := Wrap(store, dir)
+	err = r.Save(&models.Backlog{Items: []models.BacklogItem{}})
+	if !errors.Is(err, ErrNotLoaded) {
+		t.Fatalf("Save without Load: err = %v, want ErrNotLoaded", err)
+	}
+	if err := r.SaveArchive(&models.Backlog{}); !errors.Is(err, ErrNotLoaded) {
+		t.Fatalf("SaveArchive without LoadArchive: err = %v, want ErrNotLoaded", err)
+	}
+
+	backlog, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backlog.Items) != 1 {
+		t.Errorf("backlog has %d items after refused save, want 1", len(backlog.Items))
+	}
+}
+
+func TestSaveDetectsConcurrentChange(t *testing.T) {
+	dir := t.TempDir()
+	store, err := storage.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := Wrap(store, dir)
+	backlog, err := r.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process changes the backlog in the meantime
+	other, err := storage.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	theirs, err := other.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	theirs.Items = append(theirs.Items, models.BacklogItem{ID: "b", Title: "Theirs", Status: models.StatusTodo})
+	if err := other.Save(theirs); err != nil {
+		t.Fatal(err)
+	}
+
+	backlog.Items = append(backlog.Items, models.BacklogItem{ID: "c", Title: "Ours", Status: models.StatusTodo})
+	if err := r.Save(backlog); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("Save after a concurrent change: err = %v, want ErrConflict", err)
+	}
+}
+
+// newRecorder returns a recorder on an empty JSON board
+func newRecorder(t *testing.T) *Recorder {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := storage.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Wrap(store, dir)
+}
+
+// board loads every collection through r, inside one transaction with its
+// lock held until the returned unlock
+func board(t *testing.T, r *Recorder) (*boardState, func()) {
+	t.Helper()
+	unlock, err := r.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := &boardState{}
+	if state.backlog, err = r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if state.archive, err = r.LoadArchive(); err != nil {
+		t.Fatal(err)
+	}
+	if state.trash, err = r.LoadTrash(); err != nil {
+		t.Fatal(err)
+	}
+	return state, unlock
+}
+
+func saveBacklog(t *testing.T, r *Recorder, state *boardState) {
+	t.Helper()
+	if err := r.Save(state.backlog); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The changes below save in the order the Recorder documents, so moving
+// an item between collections is journaled as one event
+
+func addItem(t *testing.T, r *Recorder, id, title string) {
+	state, unlock := board(t, r)
+	defer unlock()
+	state.backlog.Items = append(state.backlog.Items, models.BacklogItem{ID: id, Title: title, Status: models.StatusTodo, CreatedAt: time.Now()})
+	saveBacklog(t, r, state)
+}
+
+func editItem(t *testing.T, r *Recorder, id string, edit func(item *models.BacklogItem)) {
+	state, unlock := board(t, r)
+	defer unlock()
+	edit(&state.backlog.Items[indexOf(state.backlog, id)])
+	saveBacklog(t, r, state)
+}
+
+func trashItem(t *testing.T, r *Recorder, id string) {
+	state, unlock := board(t, r)
+	defer unlock()
+	item := state.backlog.Items[indexOf(state.backlog, id)]
+	now := time.Now()
+	item.DeletedAt = &now
+	state.trash.Items = append(state.trash.Items, item)
+	if err := r.SaveTrash(state.trash); err != nil {
+		t.Fatal(err)
+	}
+	removeItem(state.backlog, id)
+	saveBacklog(t, r, state)
+}
+
+func archiveItem(t *testing.T, r *Recorder, id string) {
+	state, unlock := board(t, r)
+	defer unlock()
+	state.archive.Items = append(state.archive.Items, state.backlog.Items[indexOf(state.backlog, id)])
+	if err := r.SaveArchive(state.archive); err != nil {
+		t.Fatal(err)
+	}
+	removeItem(state.backlog, id)
+	saveBacklog(t, r, state)
+}
+
+func restoreItem(t *testing.T, r *Recorder, id string) {
+	state, unlock := board(t, r)
+	defer unlock()
+	item := state.trash.Items[indexOf(state.trash, id)]
+	item.DeletedAt = nil
+	state.backlog.Items = append(state.backlog.Items, item)
+	saveBacklog(t, r, state)
+	removeItem(state.trash, id)
+	if err := r.SaveTrash(state.trash); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func unarchiveItem(t *testing.T, r *Recorder, id string) {
+	state, unlock := board(t, r)
+	defer unlock()
+	state.backlog.Items = append(state.backlog.Items, state.archive.Items[indexOf(state.archive, id)])
+	saveBacklog(t, r, state)
+	removeItem(state.archive, id)
+	if err := r.SaveArchive(state.archive); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// snapshot describes where every item of the board is, with its title and
+// status
+func snapshot(t *testing.T, r *Recorder) string {
+	t.Helper()
+	state, unlock := board(t, r)
+	defer unlock()
+	var out string
+	for _, c := range []struct {
+		name  string
+		items []models.BacklogItem
+	}{{"backlog", state.backlog.Items}, {"archive", state.archive.Items}, {"trash", state.trash.Items}} {
+		out += c.name + ":"
+		for _, item := range c.items {
+			out += fmt.Sprintf(" %s/%s/%s", item.ID, item.Title, item.Status)
+		}
+		out += "\n"
+	}
+	return out
+}
+
+func TestUndoRedoRoundTrip(t *testing.T) {
+	tests := []struct {
+		event  EventType
+		setup  func(t *testing.T, r *Recorder)
+		change func(t *testing.T, r *Recorder)
+	}{
+		{EventCreated, nil, func(t *testing.T, r *Recorder) { addItem(t, r, "b", "New") }},
+		{EventFieldChanged, nil, func(t *testing.T, r *Recorder) {
+			editItem(t, r, "a", func(item *models.BacklogItem) { item.Title = "Renamed" })
+		}},
+		{EventMoved, nil, func(t *testing.T, r *Recorder) {
+			editItem(t, r, "a", func(item *models.BacklogItem) { item.Status = models.StatusInProgress })
+		}},
+		{EventDeleted, nil, func(t *testing.T, r *Recorder) { trashItem(t, r, "a") }},
+		{EventArchived, nil, func(t *testing.T, r *Recorder) { archiveItem(t, r, "a") }},
+		{EventRestored, func(t *testing.T, r *Recorder) { trashItem(t, r, "a") }, func(t *testing.T, r *Recorder) { restoreItem(t, r, "a") }},
+		{EventUnarchived, func(t *testing.T, r *Recorder) { archiveItem(t, r, "a") }, func(t *testing.T, r *Recorder) { unarchiveItem(t, r, "a") }},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.event), func(t *testing.T) {
+			r := newRecorder(t)
+			addItem(t, r, "a", "First")
+			if tt.setup != nil {
+				tt.setup(t, r)
+			}
+			before := snapshot(t, r)
+
+			tt.change(t, r)
+			after := snapshot(t, r)
+			if after == before {
+				t.Fatal("the change did nothing")
+			}
+
+			undone, err := r.Undo()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(undone.Events) != 1 || undone.Events[0].Type != tt.event {
+				t.Errorf("undid %+v, want a single %s event", undone.Events, tt.event)
+			}
+			if got := snapshot(t, r); got != before {
+				t.Errorf("after undo:\n%swant:\n%s", got, before)
+			}
+
+			if _, err := r.Redo(); err != nil {
+				t.Fatal(err)
+			}
+			if got := snapshot(t, r); got != after {
+				t.Errorf("after redo:\n%swant:\n%s", got, after)
+			}
+		})
+	}
+}
+
+func TestUndoStack(t *testing.T) {
+	r := newRecorder(t)
+	if _, err := r.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("Undo on an empty journal: err = %v, want ErrNothingToUndo", err)
+	}
+
+	addItem(t, r, "a", "First")
+	editItem(t, r, "a", func(item *models.BacklogItem) { item.Title = "Second" })
+	editItem(t, r, "a", func(item *models.BacklogItem) { item.Title = "Third" })
+
+	// Undo walks back one transaction at a time, and redo forward again
+	for _, want := range []string{"Second", "First"} {
+		if _, err := r.Undo(); err != nil {
+			t.Fatal(err)
+		}
+		if got := snapshot(t, r); got != "backlog: a/"+want+"/todo\narchive:\ntrash:\n" {
+			t.Errorf("after undo: %q, want the title %s", got, want)
+		}
+	}
+	if _, err := r.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	if got := snapshot(t, r); got != "backlog: a/Second/todo\narchive:\ntrash:\n" {
+		t.Errorf("after redo: %q, want the title Second", got)
+	}
+
+	// A new change makes the remaining undone change unreachable
+	editItem(t, r, "a", func(item *models.BacklogItem) { item.Status = models.StatusDone })
+	if _, err := r.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("Redo after a new change: err = %v, want ErrNothingToRedo", err)
+	}
+	if _, err := r.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if got := snapshot(t, r); got != "backlog: a/Second/todo\narchive:\ntrash:\n" {
+		t.Errorf("undoing the new change: %q, want it back at Second", got)
+	}
+}
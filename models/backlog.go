@@ -1,37 +1,157 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
 // Status represents the status of a backlog item
 type Status string
 
+// Statuses of the default workflow
 const (
 	StatusTodo       Status = "todo"
 	StatusInProgress Status = "in-progress"
 	StatusDone       Status = "done"
 )
 
+// Priority is the urgency of an item, P0 being the most urgent. The empty
+// Priority means none was set.
+type Priority string
+
+const (
+	PriorityP0 Priority = "P0"
+	PriorityP1 Priority = "P1"
+	PriorityP2 Priority = "P2"
+	PriorityP3 Priority = "P3"
+)
+
+// Priorities lists the valid priorities from most to least urgent
+var Priorities = []Priority{PriorityP0, PriorityP1, PriorityP2, PriorityP3}
+
+// ParsePriority parses P0-P3 in either case. An empty string or "none"
+// yields no priority.
+func ParsePriority(s string) (Priority, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" || s == "NONE" {
+		return "", nil
+	}
+	for _, p := range Priorities {
+		if Priority(s) == p {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("invalid priority %q. Use: P0, P1, P2 or P3", s)
+}
+
+// rank orders priorities for sorting; items without one come last
+func (p Priority) rank() int {
+	for i, q := range Priorities {
+		if p == q {
+			return i
+		}
+	}
+	return len(Priorities)
+}
+
 // BacklogItem represents a single backlog item
 type BacklogItem struct {
 	ID          string    `json:"id"`
+	Number      int       `json:"number,omitempty"` // Short per-board ID shown as #N, assigned on first save
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
 	DueDate     string    `json:"due_date"` // Format: DD-MM-YYYY
 	Tags        []string  `json:"tags"`
 	Status      Status    `json:"status"`
+	Priority    Priority  `json:"priority,omitempty"`
+	Rank        string    `json:"rank,omitempty"` // Manual order within a column, see RankBetween
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	History     []Change  `json:"history,omitempty"`
+
+	// Parent is the ID of the epic the item belongs to, if any
+	Parent string `json:"parent,omitempty"`
+
+	// BlockedBy holds the IDs of the items that have to be done before
+	// this one can start
+	BlockedBy []string `json:"blocked_by,omitempty"`
+
+	// Checklist breaks the item down into steps
+	Checklist []ChecklistEntry `json:"checklist,omitempty"`
+
+	// Repeat is the item's recurrence rule, see ParseRecurrence. Moving
+	// the item to done creates its next instance, which takes the rule over.
+	Repeat string `json:"repeat,omitempty"`
+
+	// Notes is a log of progress updates, oldest first
+	Notes []Note `json:"notes,omitempty"`
+
+	// TimeLog records the time spent on the item
+	TimeLog []TimeEntry `json:"time_log,omitempty"`
+
+	// TimerStarted is set while a timer is running on the item; only one
+	// item on a board has one at a time
+	TimerStarted *time.Time `json:"timer_started,omitempty"`
+
+	// DeletedAt is set while the item is in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// UnarchivedAt is when the item was last brought back from the
+	// archive. Auto-archiving counts a done item's age from then, so it
+	// doesn't archive the item again straight away.
+	UnarchivedAt *time.Time `json:"unarchived_at,omitempty"`
+}
+
+// CompletedAt returns when the item was last moved to the done status of
+// its workflow, going by its history. Items completed before history was
+// recorded fall back to their last update.
+func (item BacklogItem) CompletedAt(done Status) time.Time {
+	for i := len(item.History) - 1; i >= 0; i-- {
+		change := item.History[i]
+		if change.Field == "status" && change.New == string(done) {
+			return change.At
+		}
+	}
+	return item.UpdatedAt
+}
+
+// Change records a single field-level change to an item. Old and New
+// are display forms of the values; empty means unset.
+type Change struct {
+	At    time.Time `json:"at"`
+	Field string    `json:"field"`
+	Old   string    `json:"old,omitempty"`
+	New   string    `json:"new,omitempty"`
 }
 
 // Backlog represents the collection of all backlog items
 type Backlog struct {
-	Items []BacklogItem `json:"items"`
+	// Version is the schema version of the file the backlog was stored in.
+	// It is maintained by the storage package.
+	Version int `json:"version"`
+
+	// NextNumber is the number the next new item will get. Numbers are
+	// never reused; only the active backlog keeps the counter.
+	NextNumber int           `json:"next_number,omitempty"`
+	Items      []BacklogItem `json:"items"`
 }
 
-// ValidStatus checks if a status string is valid
-func ValidStatus(s string) bool {
-	return s == string(StatusTodo) || s == string(StatusInProgress) || s == string(StatusDone)
+// AssignNumbers gives every item that has no number yet the next free one
+func (b *Backlog) AssignNumbers() {
+	for i := range b.Items {
+		if b.Items[i].Number == 0 {
+			b.Items[i].Number = b.TakeNumber()
+		}
+	}
 }
 
+// TakeNumber reserves the next free item number
+func (b *Backlog) TakeNumber() int {
+	if b.NextNumber < 1 {
+		b.NextNumber = 1
+	}
+	n := b.NextNumber
+	b.NextNumber++
+	return n
+}
@@ -0,0 +1,34 @@
+package models
+
+import "fmt"
+
+// ChecklistEntry is one step of an item's checklist
+type ChecklistEntry struct {
+	Text string `json:"text"`
+	Done bool   `json:"done,omitempty"`
+}
+
+// ChecklistProgress returns how many checklist entries are done, out of
+// how many
+func (item BacklogItem) ChecklistProgress() (done, total int) {
+	return checklistProgress(item.Checklist)
+}
+
+// ChecklistSummary renders checklist progress like "2/5", or "" for items
+// without a checklist
+func ChecklistSummary(checklist []ChecklistEntry) string {
+	done, total := checklistProgress(checklist)
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", done, total)
+}
+
+func checklistProgress(checklist []ChecklistEntry) (done, total int) {
+	for _, entry := range checklist {
+		if entry.Done {
+			done++
+		}
+	}
+	return done, len(checklist)
+}
@@ -0,0 +1,87 @@
+package models
+
+import "fmt"
+
+// ErrParentCycle is returned by CheckParent when the new parent is the
+// item itself or one of its descendants
+type ErrParentCycle struct {
+	Item, Parent BacklogItem
+}
+
+func (e *ErrParentCycle) Error() string {
+	if e.Item.ID == e.Parent.ID {
+		return fmt.Sprintf("%s can't be its own parent", e.Item.Ref())
+	}
+	return fmt.Sprintf("%s can't be the parent of %s: it is already below it", e.Parent.Ref(), e.Item.Ref())
+}
+
+// CheckParent returns an *ErrParentCycle if making parent the parent of
+// item would make the hierarchy loop back on itself
+func CheckParent(items []BacklogItem, item, parent BacklogItem) error {
+	byID := map[string]BacklogItem{}
+	for _, i := range items {
+		byID[i.ID] = i
+	}
+
+	seen := map[string]bool{}
+	for id := parent.ID; id != "" && !seen[id]; id = byID[id].Parent {
+		if id == item.ID {
+			return &ErrParentCycle{Item: item, Parent: parent}
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// Children returns the items whose parent is the item with the given ID
+func Children(items []BacklogItem, id string) []BacklogItem {
+	var children []BacklogItem
+	for _, item := range items {
+		if item.Parent == id {
+			children = append(children, item)
+		}
+	}
+	return children
+}
+
+// Descendants returns the IDs of the children of the item with the given
+// ID, their children, and so on
+func Descendants(items []BacklogItem, id string) map[string]bool {
+	descendants := map[string]bool{}
+	queue := []string{id}
+	for len(queue) > 0 {
+		for _, child := range Children(items, queue[0]) {
+			if !descendants[child.ID] {
+				descendants[child.ID] = true
+				queue = append(queue, child.ID)
+			}
+		}
+		queue = queue[1:]
+	}
+	return descendants
+}
+
+// ChildProgress returns how many of the items below the item with the
+// given ID are in the done status, out of how many there are. It counts
+// children, their children and so on, so an epic's progress includes that
+// of its sub-epics. Archived items are only archived once done, so those
+// below the item count as completed.
+func ChildProgress(items, archived []BacklogItem, id string, done Status) (completed, total int) {
+	isArchived := map[string]bool{}
+	for _, item := range archived {
+		isArchived[item.ID] = true
+	}
+
+	all := append(items[:len(items):len(items)], archived...)
+	descendants := Descendants(all, id)
+	for _, item := range all {
+		if !descendants[item.ID] {
+			continue
+		}
+		if item.Status == done || isArchived[item.ID] {
+			completed++
+		}
+		total++
+	}
+	return completed, total
+}
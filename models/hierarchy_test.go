@@ -0,0 +1,39 @@
+package models
+
+import "testing"
+
+func TestChildProgress(t *testing.T) {
+	items := []BacklogItem{
+		{ID: "epic", Status: StatusInProgress},
+		{ID: "a", Parent: "epic", Status: StatusDone},
+		{ID: "b", Parent: "epic", Status: StatusTodo},
+		{ID: "sub", Parent: "epic", Status: StatusInProgress},
+		{ID: "c", Parent: "sub", Status: StatusDone},
+		{ID: "d", Parent: "sub", Status: StatusTodo},
+		{ID: "other", Status: StatusDone},
+	}
+	archived := []BacklogItem{
+		{ID: "e", Parent: "epic", Status: StatusDone},
+		{ID: "f", Parent: "sub", Status: StatusDone},
+		{ID: "g", Parent: "other", Status: StatusDone},
+	}
+
+	tests := []struct {
+		id                  string
+		archived            []BacklogItem
+		wantDone, wantTotal int
+	}{
+		// a, c and e are done and f archived, out of a, b, sub, c, d, e and f
+		{"epic", archived, 4, 7},
+		{"sub", archived, 2, 3},
+		{"epic", nil, 2, 5},
+		{"a", archived, 0, 0},
+	}
+	for _, tt := range tests {
+		done, total := ChildProgress(items, tt.archived, tt.id, StatusDone)
+		if done != tt.wantDone || total != tt.wantTotal {
+			t.Errorf("ChildProgress(%s, %d archived) = %d/%d, want %d/%d",
+				tt.id, len(tt.archived), done, total, tt.wantDone, tt.wantTotal)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package models
+
+import "fmt"
+
+// ErrBlockCycle is returned by CheckBlock when a new link would make
+// items wait on each other
+type ErrBlockCycle struct {
+	Blocker, Blocked BacklogItem
+}
+
+func (e *ErrBlockCycle) Error() string {
+	if e.Blocker.ID == e.Blocked.ID {
+		return fmt.Sprintf("%s can't block itself", e.Blocker.Ref())
+	}
+	return fmt.Sprintf("%s can't block %s: %s already blocks %s, directly or through other items",
+		e.Blocker.Ref(), e.Blocked.Ref(), e.Blocked.Ref(), e.Blocker.Ref())
+}
+
+// CheckBlock returns an *ErrBlockCycle if making blocker block blocked
+// would create a cycle of items waiting on each other
+func CheckBlock(items []BacklogItem, blocker, blocked BacklogItem) error {
+	byID := map[string]BacklogItem{}
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	// Walk everything the blocker waits on, looking for the blocked item
+	seen := map[string]bool{}
+	stack := []string{blocker.ID}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == blocked.ID {
+			return &ErrBlockCycle{Blocker: blocker, Blocked: blocked}
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		stack = append(stack, byID[id].BlockedBy...)
+	}
+	return nil
+}
+
+// IsBlockedBy reports whether the item lists the item with the given ID
+// as a blocker
+func (item BacklogItem) IsBlockedBy(id string) bool {
+	for _, b := range item.BlockedBy {
+		if b == id {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenBlockers returns the items among items that block item and aren't in
+// the done status yet. Blockers that have left the board, by being
+// archived or deleted, no longer count.
+func OpenBlockers(items []BacklogItem, item BacklogItem, done Status) []BacklogItem {
+	var open []BacklogItem
+	for _, other := range items {
+		if other.Status != done && item.IsBlockedBy(other.ID) {
+			open = append(open, other)
+		}
+	}
+	return open
+}
@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+// Note is a timestamped entry in an item's notes log
+type Note struct {
+	At   time.Time `json:"at"`
+	Text string    `json:"text"`
+}
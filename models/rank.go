@@ -0,0 +1,150 @@
+package models
+
+// Ranks order items manually within a column. They are strings over the
+// letters a-z compared lexicographically, so an item can always be placed
+// between two others by giving it a new rank, without touching any other
+// item. Ranks produced by RankBetween never end in 'a', which keeps room
+// below every rank.
+
+const (
+	rankFirstDigit = 'a'
+	rankBase       = 26
+)
+
+// RankBetween returns a rank that sorts after a and before b. An empty a
+// means no lower bound and an empty b means no upper bound. a must sort
+// before b, and both must have been produced by RankBetween.
+func RankBetween(a, b string) string {
+	var rank []byte
+	for i := 0; ; i++ {
+		lo := 0
+		if i < len(a) {
+			lo = int(a[i] - rankFirstDigit)
+		}
+		hi := rankBase
+		if b != "" && i < len(b) {
+			hi = int(b[i] - rankFirstDigit)
+		}
+
+		if lo == hi {
+			rank = append(rank, byte(rankFirstDigit+lo))
+			continue
+		}
+
+		mid := (lo + hi) / 2
+		if mid > lo {
+			return string(append(rank, byte(rankFirstDigit+mid)))
+		}
+
+		// The digits are adjacent, so keep a's digit and look for room
+		// further down, where b no longer constrains the result
+		rank = append(rank, byte(rankFirstDigit+lo))
+		b = ""
+	}
+}
+
+// RankLast returns the rank for the item with the given id when it moves
+// into the column of items with the given status, placing it after every
+// ranked item there. It returns no rank when nothing in the column is
+// ranked, so the item sorts with the others by priority and due date.
+func RankLast(items []BacklogItem, status Status, id string) string {
+	last := ""
+	for _, item := range items {
+		if item.Status == status && item.ID != id && item.Rank > last {
+			last = item.Rank
+		}
+	}
+	if last == "" {
+		return ""
+	}
+	return RankBetween(last, "")
+}
+
+// Reorder moves the item at index from of column, a slice of items in
+// display order (see SortItems), so that it ends up at index to. It gives
+// the moved item a rank between its new neighbours, first ranking any
+// unranked items that now sort before it, and returns the items whose rank
+// changed.
+func Reorder(column []BacklogItem, from, to int) []BacklogItem {
+	order := make([]BacklogItem, 0, len(column))
+	order = append(order, column[:from]...)
+	order = append(order, column[from+1:]...)
+	moved := column[from]
+	order = append(order[:to], append([]BacklogItem{moved}, order[to:]...)...)
+
+	// Ranks that tie or run backwards leave no room between neighbours,
+	// so the column is ranked afresh instead
+	if !ranksIncrease(order, to) {
+		return respace(order, to)
+	}
+
+	var changed []BacklogItem
+
+	// Ranked items sort before unranked ones, so everything above the
+	// new position needs a rank for the move to stick
+	for i := 0; i < to; i++ {
+		if order[i].Rank != "" {
+			continue
+		}
+		prev := ""
+		if i > 0 {
+			prev = order[i-1].Rank
+		}
+		order[i].Rank = RankBetween(prev, "")
+		changed = append(changed, order[i])
+	}
+
+	prev, next := "", ""
+	if to > 0 {
+		prev = order[to-1].Rank
+	}
+	if to+1 < len(order) {
+		next = order[to+1].Rank
+	}
+	order[to].Rank = RankBetween(prev, next)
+
+	return append(changed, order[to])
+}
+
+// ranksIncrease reports whether the ranked items of order, other than the
+// one at index skip, have strictly increasing ranks
+func ranksIncrease(order []BacklogItem, skip int) bool {
+	prev := ""
+	for i, item := range order {
+		if i == skip || item.Rank == "" {
+			continue
+		}
+		if item.Rank <= prev {
+			return false
+		}
+		prev = item.Rank
+	}
+	return true
+}
+
+// respace gives the items of order fresh ranks in order, down to the item
+// at index to or the last ranked item, whichever is lower, and returns the
+// items whose rank changed. The item at index to is always returned last.
+func respace(order []BacklogItem, to int) []BacklogItem {
+	last := to
+	for i := range order {
+		if order[i].Rank != "" && i > last {
+			last = i
+		}
+	}
+
+	var changed []BacklogItem
+	prev := ""
+	for i := 0; i <= last; i++ {
+		rank := RankBetween(prev, "")
+		prev = rank
+		if order[i].Rank == rank && i != to {
+			continue
+		}
+		order[i].Rank = rank
+		if i != to {
+			changed = append(changed, order[i])
+		}
+	}
+	return append(changed, order[to])
+}
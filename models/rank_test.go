@@ -0,0 +1,114 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRankBetween(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"empty column", "", ""},
+		{"before the first", "", "n"},
+		{"after the last", "n", ""},
+		{"between", "n", "u"},
+		{"adjacent", "n", "o"},
+		{"adjacent at the end of the range", "y", "z"},
+		{"after the highest digit", "z", ""},
+		{"after a run of highest digits", "zzz", ""},
+		{"before the lowest rank", "", "b"},
+		{"longer upper bound", "n", "nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RankBetween(tt.a, tt.b)
+			if got <= tt.a || (tt.b != "" && got >= tt.b) {
+				t.Errorf("RankBetween(%q, %q) = %q, not between them", tt.a, tt.b, got)
+			}
+			if strings.HasSuffix(got, "a") {
+				t.Errorf("RankBetween(%q, %q) = %q, which ends in 'a'", tt.a, tt.b, got)
+			}
+		})
+	}
+}
+
+// ranked returns a column of items with the given ranks, named by index
+func ranked(ranks ...string) []BacklogItem {
+	column := make([]BacklogItem, len(ranks))
+	for i, rank := range ranks {
+		column[i] = BacklogItem{ID: string(rune('0' + i)), Rank: rank}
+	}
+	return column
+}
+
+// applyReorder moves an item within column and returns the IDs in the
+// order the new ranks sort them
+func applyReorder(column []BacklogItem, from, to int) string {
+	byID := map[string]*BacklogItem{}
+	for i := range column {
+		byID[column[i].ID] = &column[i]
+	}
+	for _, item := range Reorder(column, from, to) {
+		byID[item.ID].Rank = item.Rank
+	}
+	SortItems(column)
+
+	var ids strings.Builder
+	for _, item := range column {
+		ids.WriteString(item.ID)
+	}
+	return ids.String()
+}
+
+func TestReorder(t *testing.T) {
+	tests := []struct {
+		name     string
+		column   []BacklogItem
+		from, to int
+		want     string
+	}{
+		{"up", ranked("b", "n", "u"), 2, 1, "021"},
+		{"down", ranked("b", "n", "u"), 0, 2, "120"},
+		{"to the top", ranked("b", "n", "u"), 2, 0, "201"},
+		{"unranked below", ranked("n", "", ""), 2, 1, "021"},
+		{"nothing ranked", ranked("", "", ""), 1, 0, "102"},
+		{"equal ranks", ranked("n", "n", "t"), 2, 1, "021"},
+		{"equal ranks around the moved item", ranked("n", "t", "t", "t"), 0, 2, "1203"},
+		{"ranks out of order", ranked("u", "n", ""), 2, 0, "201"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyReorder(tt.column, tt.from, tt.to); got != tt.want {
+				t.Errorf("order after moving %d to %d = %s, want %s", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReorderChangesOnlyNeighbours(t *testing.T) {
+	column := ranked("b", "n", "u", "x")
+	changed := Reorder(column, 3, 1)
+	if len(changed) != 1 || changed[0].ID != "3" {
+		t.Errorf("changed = %+v, want only the moved item", changed)
+	}
+}
+
+func TestRankLast(t *testing.T) {
+	items := []BacklogItem{
+		{ID: "1", Status: StatusTodo, Rank: "n"},
+		{ID: "2", Status: StatusTodo, Rank: "u"},
+		{ID: "3", Status: StatusDone, Rank: "z"},
+		{ID: "4", Status: StatusInProgress},
+	}
+	if rank := RankLast(items, StatusTodo, "3"); rank <= "u" {
+		t.Errorf("rank in todo = %q, want one after %q", rank, "u")
+	}
+	if rank := RankLast(items, StatusInProgress, "1"); rank != "" {
+		t.Errorf("rank in a column without ranks = %q, want none", rank)
+	}
+	if rank := RankLast(items, StatusDone, "3"); rank != "" {
+		t.Errorf("rank for the only ranked item of its column = %q, want none", rank)
+	}
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Units a recurrence repeats in
+const (
+	RepeatDays   = "day"
+	RepeatWeeks  = "week"
+	RepeatMonths = "month"
+)
+
+// Recurrence is a rule for repeating an item. It is stored on items in its
+// text form, see ParseRecurrence.
+type Recurrence struct {
+	Unit     string
+	Every    int            // days between instances, for RepeatDays
+	Weekdays []time.Weekday // for RepeatWeeks; none means the due date's weekday
+	Day      int            // day of the month, for RepeatMonths; 0 means the due date's
+}
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// parseWeekday reads a weekday given by its full name or its three-letter
+// abbreviation
+func parseWeekday(s string) (time.Weekday, bool) {
+	for wd, name := range weekdayNames {
+		if s == name || s == strings.ToLower(time.Weekday(wd).String()) {
+			return time.Weekday(wd), true
+		}
+	}
+	return 0, false
+}
+
+// invalidRepeat is the error for a rule ParseRecurrence doesn't understand
+func invalidRepeat(s string) error {
+	return fmt.Errorf("invalid repeat rule %q. Use: daily, weekly, weekly:mon,thu, monthly, monthly:31 or every:3d", s)
+}
+
+// ParseRecurrence parses a rule: "daily", "weekly", "weekly:mon,thu",
+// "monthly", "monthly:31" (on the 31st, or the last day of shorter months)
+// or "every:3d" (every three days)
+func ParseRecurrence(s string) (Recurrence, error) {
+	rule := strings.ToLower(strings.TrimSpace(s))
+	name, arg, hasArg := strings.Cut(rule, ":")
+
+	switch {
+	case rule == "daily":
+		return Recurrence{Unit: RepeatDays, Every: 1}, nil
+
+	case name == "monthly":
+		r := Recurrence{Unit: RepeatMonths}
+		if !hasArg {
+			return r, nil
+		}
+		day, err := strconv.Atoi(arg)
+		if err != nil || day < 1 || day > 31 {
+			return Recurrence{}, invalidRepeat(s)
+		}
+		r.Day = day
+		return r, nil
+
+	case name == "weekly":
+		r := Recurrence{Unit: RepeatWeeks}
+		if !hasArg {
+			return r, nil
+		}
+		seen := make(map[time.Weekday]bool)
+		for _, day := range strings.Split(arg, ",") {
+			wd, ok := parseWeekday(strings.TrimSpace(day))
+			if !ok {
+				return Recurrence{}, invalidRepeat(s)
+			}
+			if !seen[wd] {
+				seen[wd] = true
+				r.Weekdays = append(r.Weekdays, wd)
+			}
+		}
+		return r, nil
+
+	case name == "every" && hasArg:
+		n, err := strconv.Atoi(strings.TrimSuffix(arg, "d"))
+		if err != nil || n < 1 {
+			return Recurrence{}, invalidRepeat(s)
+		}
+		return Recurrence{Unit: RepeatDays, Every: n}, nil
+	}
+
+	return Recurrence{}, invalidRepeat(s)
+}
+
+// String returns the rule in the form ParseRecurrence reads
+func (r Recurrence) String() string {
+	switch r.Unit {
+	case RepeatDays:
+		if r.Every == 1 {
+			return "daily"
+		}
+		return fmt.Sprintf("every:%dd", r.Every)
+	case RepeatWeeks:
+		if len(r.Weekdays) == 0 {
+			return "weekly"
+		}
+		days := make([]string, len(r.Weekdays))
+		for i, wd := range r.Weekdays {
+			days[i] = weekdayNames[wd]
+		}
+		return "weekly:" + strings.Join(days, ",")
+	case RepeatMonths:
+		if r.Day == 0 {
+			return "monthly"
+		}
+		return fmt.Sprintf("monthly:%d", r.Day)
+	}
+	return ""
+}
+
+// Next returns the first day the rule falls on after t
+func (r Recurrence) Next(t time.Time) time.Time {
+	switch r.Unit {
+	case RepeatWeeks:
+		if len(r.Weekdays) == 0 {
+			return t.AddDate(0, 0, 7)
+		}
+		for days := 1; ; days++ {
+			next := t.AddDate(0, 0, days)
+			for _, wd := range r.Weekdays {
+				if next.Weekday() == wd {
+					return next
+				}
+			}
+		}
+	case RepeatMonths:
+		// Stay on the rule's day of the month, or t's if it has none, or
+		// the last day of a shorter month
+		day := r.Day
+		if day == 0 {
+			day = t.Day()
+		}
+		next := time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		last := next.AddDate(0, 1, -1).Day()
+		return next.AddDate(0, 0, min(day, last)-1)
+	}
+	return t.AddDate(0, 0, max(r.Every, 1))
+}
+
+// NextInstance returns a fresh copy of a recurring item for its next
+// occurrence: due on the first day after its current due date (or today,
+// if it has none) that the rule falls on and that isn't in the past. The
+// copy starts in status and keeps the rule; ID and Number are left to the
+// caller and the store. A monthly rule is pinned to the day of the month
+// of that starting date, so instances clamped to the end of a short month
+// return to it in longer ones.
+func (item BacklogItem) NextInstance(status Status, now time.Time) (BacklogItem, error) {
+	r, err := ParseRecurrence(item.Repeat)
+	if err != nil {
+		return BacklogItem{}, err
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	due, ok := dueTime(item)
+	if !ok {
+		due = today
+	}
+	if r.Unit == RepeatMonths && r.Day == 0 {
+		r.Day = due.Day()
+	}
+	due = r.Next(due)
+	for due.Before(today) {
+		due = r.Next(due)
+	}
+
+	next := BacklogItem{
+		Title:       item.Title,
+		Description: item.Description,
+		DueDate:     due.Format("02-01-2006"),
+		Tags:        append([]string(nil), item.Tags...),
+		Status:      status,
+		Priority:    item.Priority,
+		Parent:      item.Parent,
+		Repeat:      r.String(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	for _, entry := range item.Checklist {
+		next.Checklist = append(next.Checklist, ChecklistEntry{Text: entry.Text})
+	}
+	return next, nil
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	tests := []struct {
+		rule string
+		from string
+		want string
+	}{
+		{"daily", "2026-02-28", "2026-03-01"},
+		{"every:3d", "2026-12-30", "2027-01-02"},
+		{"weekly", "2026-10-19", "2026-10-26"},
+		{"weekly:mon,thu", "2026-10-19", "2026-10-22"}, // Monday to Thursday
+		{"weekly:mon,thu", "2026-10-22", "2026-10-26"}, // Thursday to Monday
+		{"monthly", "2026-10-15", "2026-11-15"},
+		{"monthly", "2026-01-31", "2026-02-28"}, // clamped to the month's end
+		{"monthly", "2028-01-31", "2028-02-29"}, // leap year
+		{"monthly", "2028-02-29", "2028-03-29"}, // stays on the leap day's date
+		{"monthly", "2026-03-31", "2026-04-30"},
+		{"monthly", "2026-12-31", "2027-01-31"},    // across the year end
+		{"monthly:31", "2026-02-28", "2026-03-31"}, // back to its day after a short month
+		{"monthly:30", "2026-01-30", "2026-02-28"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule+" from "+tt.from, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.Next(date(tt.from)).Format("2006-01-02"); got != tt.want {
+				t.Errorf("Next = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string // canonical form, or "" for an invalid rule
+	}{
+		{"Daily", "daily"},
+		{"every:1d", "daily"},
+		{"every:14", "every:14d"},
+		{"weekly:thursday, MON, thu", "weekly:thu,mon"},
+		{"monthly", "monthly"},
+		{"Monthly:07", "monthly:7"},
+		{"weekly:Sunday,sat", "weekly:sun,sat"},
+		{"every:0d", ""},
+		{"weekly:mo", ""},
+		{"weekly:sunxyz", ""},
+		{"weekly:thurs", ""},
+		{"monthly:0", ""},
+		{"monthly:32", ""},
+		{"monthly:last", ""},
+		{"yearly", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.rule)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("ParseRecurrence(%q) = %v, want an error", tt.rule, r)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("ParseRecurrence(%q) = %s, want %s", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextInstanceSkipsPastDates(t *testing.T) {
+	item := BacklogItem{Title: "Review", DueDate: "05-10-2026", Repeat: "weekly", Tags: []string{"ops"}}
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+
+	next, err := item.NextInstance(StatusTodo, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.DueDate != "19-10-2026" {
+		t.Errorf("due = %s, want the first Monday that isn't past, 19-10-2026", next.DueDate)
+	}
+	if next.Repeat != "weekly" || next.Status != StatusTodo || len(next.Tags) != 1 {
+		t.Errorf("next instance = %+v", next)
+	}
+}
+
+func TestMonthlyInstancesKeepTheirDay(t *testing.T) {
+	item := BacklogItem{Title: "Invoice", DueDate: "31-01-2026", Repeat: "monthly"}
+
+	// Each instance is completed on its due date
+	for _, want := range []string{"28-02-2026", "31-03-2026", "30-04-2026", "31-05-2026"} {
+		due, _ := time.Parse("02-01-2006", item.DueDate)
+		next, err := item.NextInstance(StatusTodo, due)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next.DueDate != want {
+			t.Fatalf("after %s: due = %s, want %s", item.DueDate, next.DueDate, want)
+		}
+		if next.Repeat != "monthly:31" {
+			t.Errorf("after %s: repeat = %s, want it pinned to monthly:31", item.DueDate, next.Repeat)
+		}
+		item = next
+	}
+}
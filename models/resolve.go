@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrItemNotFound is returned by Resolve when no item matches
+var ErrItemNotFound = errors.New("item not found")
+
+// AmbiguousIDError is returned by Resolve when a reference matches more
+// than one item
+type AmbiguousIDError struct {
+	Ref        string
+	Candidates []BacklogItem
+}
+
+func (e *AmbiguousIDError) Error() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "%s matches %d items, use its #number or a longer ID:", e.Ref, len(e.Candidates))
+	for _, item := range e.Candidates {
+		fmt.Fprintf(&s, "\n  %-6s %s  %s", item.Ref(), item.ID, item.Title)
+	}
+	return s.String()
+}
+
+// Ref returns the short reference users type to refer to the item: #N,
+// or the full ID for items saved before numbers were introduced
+func (item BacklogItem) Ref() string {
+	if item.Number > 0 {
+		return fmt.Sprintf("#%d", item.Number)
+	}
+	return item.ID
+}
+
+// Resolve returns the index of the item that ref refers to. In order of
+// precedence, ref may be
+//
+//   - a full item ID
+//   - an item number, with or without a leading # (#42 or 42)
+//   - a prefix of a single item's ID
+//
+// A prefix shared by several items is refused with an *AmbiguousIDError
+// listing them, so a command never acts on an item the user didn't mean.
+func Resolve(items []BacklogItem, ref string) (int, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || ref == "#" {
+		return -1, fmt.Errorf("%w: empty ID", ErrItemNotFound)
+	}
+
+	for i := range items {
+		if items[i].ID == ref {
+			return i, nil
+		}
+	}
+
+	digits, explicit := strings.CutPrefix(ref, "#")
+	if n, err := strconv.Atoi(digits); err == nil && n > 0 {
+		for i := range items {
+			if items[i].Number == n {
+				return i, nil
+			}
+		}
+	}
+	if explicit {
+		return -1, fmt.Errorf("%w: %s", ErrItemNotFound, ref)
+	}
+
+	var matches []int
+	for i := range items {
+		if strings.HasPrefix(items[i].ID, ref) {
+			matches = append(matches, i)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return -1, fmt.Errorf("%w: %s", ErrItemNotFound, ref)
+	case 1:
+		return matches[0], nil
+	default:
+		candidates := make([]BacklogItem, len(matches))
+		for i, j := range matches {
+			candidates[i] = items[j]
+		}
+		return -1, &AmbiguousIDError{Ref: ref, Candidates: candidates}
+	}
+}
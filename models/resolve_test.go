@@ -0,0 +1,80 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	items := []BacklogItem{
+		{ID: "1760000000111", Number: 1, Title: "First"},
+		{ID: "1760000000222", Number: 2, Title: "Second"},
+		{ID: "2", Number: 3, Title: "Legacy ID that looks like a number"},
+		{ID: "1760000000333", Title: "Saved before numbers"},
+		{ID: "42abc", Number: 42, Title: "Forty-two"},
+	}
+
+	tests := []struct {
+		ref  string
+		want int
+	}{
+		{"1760000000222", 1},   // full ID
+		{" 1760000000222 ", 1}, // surrounding space is ignored
+		{"#1", 0},              // number
+		{"1", 0},               // bare number
+		{"#42", 4},
+		{"42", 4},            // a number before an ID prefix
+		{"2", 2},             // a full ID before a number
+		{"#2", 1},            // # always means a number
+		{"3", 2},             // number 3
+		{"1760000000333", 3}, // full ID of an unnumbered item
+		{"17600000003", 3},   // unique prefix
+		{"42a", 4},           // unique prefix that isn't a number
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := Resolve(items, tt.ref)
+			if err != nil {
+				t.Fatalf("Resolve(%q): %v", tt.ref, err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve(%q) = %s, want %s", tt.ref, items[got].Title, items[tt.want].Title)
+			}
+		})
+	}
+}
+
+func TestResolveRefusesAmbiguousPrefix(t *testing.T) {
+	items := []BacklogItem{
+		{ID: "1760000000111", Number: 1, Title: "First"},
+		{ID: "1760000000222", Number: 2, Title: "Second"},
+		{ID: "9", Number: 3, Title: "Other"},
+	}
+
+	_, err := Resolve(items, "176")
+	var ambiguous *AmbiguousIDError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("Resolve of a shared prefix: err = %v, want an AmbiguousIDError", err)
+	}
+	if len(ambiguous.Candidates) != 2 || ambiguous.Candidates[0].ID != items[0].ID || ambiguous.Candidates[1].ID != items[1].ID {
+		t.Errorf("candidates = %+v, want the two matching items", ambiguous.Candidates)
+	}
+	for _, want := range []string{"#1", "#2", "First", "Second"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't list %s", err, want)
+		}
+	}
+}
+
+func TestResolveNotFound(t *testing.T) {
+	items := []BacklogItem{{ID: "1760000000111", Number: 1, Title: "First"}}
+	for _, ref := range []string{"", " ", "#", "#7", "#x", "7", "abc", "17600000001112", "0"} {
+		if i, err := Resolve(items, ref); !errors.Is(err, ErrItemNotFound) {
+			t.Errorf("Resolve(%q) = %d, %v, want ErrItemNotFound", ref, i, err)
+		}
+	}
+	if _, err := Resolve(nil, "1"); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("Resolve on no items: err = %v, want ErrItemNotFound", err)
+	}
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SortItems puts manually ranked items first, in rank order. The rest
+// follow by priority, most urgent first, then by due date, soonest first.
+// Items without a priority or due date come after those with one; ties
+// keep their existing order.
+func SortItems(items []BacklogItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.Rank != "" || b.Rank != "" {
+			switch {
+			case a.Rank == "":
+				return false
+			case b.Rank == "":
+				return true
+			case a.Rank != b.Rank:
+				return a.Rank < b.Rank
+			}
+		}
+
+		if ra, rb := a.Priority.rank(), b.Priority.rank(); ra != rb {
+			return ra < rb
+		}
+
+		da, okA := dueTime(a)
+		db, okB := dueTime(b)
+		switch {
+		case okA && okB:
+			return da.Before(db)
+		default:
+			return okA && !okB
+		}
+	})
+}
+
+// SortOrders lists the orders SortItemsBy accepts. "rank" is the order of
+// SortItems.
+var SortOrders = []string{"rank", "priority", "due", "created", "updated", "title"}
+
+// CheckSortOrder returns an error unless order is one of SortOrders
+func CheckSortOrder(order string) error {
+	for _, o := range SortOrders {
+		if order == o {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid sort order %q. Use: %s", order, strings.Join(SortOrders, ", "))
+}
+
+// SortItemsBy sorts items in the named order: "priority" most urgent
+// first, ignoring manual ranks, "due" soonest first, "created" and
+// "updated" most recent first, "title" alphabetically. Ties, and the
+// empty or "rank" order, keep the order of SortItems.
+func SortItemsBy(items []BacklogItem, order string) {
+	SortItems(items)
+
+	var less func(a, b BacklogItem) bool
+	switch order {
+	case "priority":
+		less = func(a, b BacklogItem) bool { return a.Priority.rank() < b.Priority.rank() }
+	case "due":
+		less = func(a, b BacklogItem) bool {
+			da, okA := dueTime(a)
+			db, okB := dueTime(b)
+			if okA && okB {
+				return da.Before(db)
+			}
+			return okA && !okB
+		}
+	case "created":
+		less = func(a, b BacklogItem) bool { return a.CreatedAt.After(b.CreatedAt) }
+	case "updated":
+		less = func(a, b BacklogItem) bool { return a.UpdatedAt.After(b.UpdatedAt) }
+	case "title":
+		less = func(a, b BacklogItem) bool { return strings.ToLower(a.Title) < strings.ToLower(b.Title) }
+	default:
+		return
+	}
+	sort.SliceStable(items, func(i, j int) bool { return less(items[i], items[j]) })
+}
+
+// dueTime parses an item's due date
+func dueTime(item BacklogItem) (time.Time, bool) {
+	if item.DueDate == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("02-01-2006", item.DueDate)
+	return t, err == nil
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// TimeEntry records a stretch of time spent on an item
+type TimeEntry struct {
+	Start   time.Time `json:"start"`
+	Seconds int64     `json:"seconds"`
+	Logged  bool      `json:"logged,omitempty"` // entered by hand rather than timed
+}
+
+// Duration returns how long the entry lasted
+func (e TimeEntry) Duration() time.Duration {
+	return time.Duration(e.Seconds) * time.Second
+}
+
+// NewTimeEntry returns an entry for d starting at start
+func NewTimeEntry(start time.Time, d time.Duration, logged bool) TimeEntry {
+	return TimeEntry{Start: start, Seconds: int64(d / time.Second), Logged: logged}
+}
+
+// TimeSpent returns the total time logged on the item, not counting a
+// running timer
+func (item BacklogItem) TimeSpent() time.Duration {
+	var total time.Duration
+	for _, entry := range item.TimeLog {
+		total += entry.Duration()
+	}
+	return total
+}
+
+// LogTime appends an entry to the item's time log
+func (item *BacklogItem) LogTime(entry TimeEntry) {
+	// Copy so the appended entry never lands in an array shared with
+	// another copy of the item
+	item.TimeLog = append(append([]TimeEntry(nil), item.TimeLog...), entry)
+}
+
+// StopTimer logs the time since the item's timer was started and clears
+// it. It returns the new entry, or false if no timer was running.
+func (item *BacklogItem) StopTimer(now time.Time) (TimeEntry, bool) {
+	if item.TimerStarted == nil {
+		return TimeEntry{}, false
+	}
+	entry := NewTimeEntry(*item.TimerStarted, now.Sub(*item.TimerStarted), false)
+	item.LogTime(entry)
+	item.TimerStarted = nil
+	return entry, true
+}
+
+// RunningTimer returns the index of the item whose timer is running, or -1
+func RunningTimer(items []BacklogItem) int {
+	for i := range items {
+		if items[i].TimerStarted != nil {
+			return i
+		}
+	}
+	return -1
+}
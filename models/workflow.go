@@ -0,0 +1,232 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StatusDef is one status of a workflow, shown as a column on the board
+type StatusDef struct {
+	Status Status `json:"status"`
+	Name   string `json:"name,omitempty"`  // Display name; defaults to the status itself
+	Limit  int    `json:"limit,omitempty"` // WIP limit; 0 means none
+}
+
+// Label returns the display name of the status
+func (d StatusDef) Label() string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return string(d.Status)
+}
+
+// Workflow is the ordered set of statuses items on a board move through
+type Workflow struct {
+	// Statuses lists the board's columns from left to right. New items
+	// start in the first one.
+	Statuses []StatusDef `json:"statuses"`
+
+	// Done is the status that counts as completed, for archiving
+	Done Status `json:"done"`
+
+	// Transitions lists the statuses an item may move to from each
+	// status. Statuses without an entry may move anywhere; a nil map
+	// allows every move.
+	Transitions map[Status][]Status `json:"transitions,omitempty"`
+
+	// StrictWIP refuses moves that would exceed a WIP limit instead of
+	// only warning about them
+	StrictWIP bool `json:"strict_wip,omitempty"`
+}
+
+// DefaultWorkflow returns the todo, in-progress, done workflow boards use
+// unless configured otherwise
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		Statuses: []StatusDef{
+			{Status: StatusTodo, Name: "Todo"},
+			{Status: StatusInProgress, Name: "In Progress"},
+			{Status: StatusDone, Name: "Done"},
+		},
+		Done: StatusDone,
+	}
+}
+
+// Validate checks that the workflow is usable: it has statuses, they are
+// unique, and the done status and transitions only refer to them
+func (w *Workflow) Validate() error {
+	if len(w.Statuses) == 0 {
+		return fmt.Errorf("workflow has no statuses")
+	}
+
+	seen := map[Status]bool{}
+	for _, def := range w.Statuses {
+		s := string(def.Status)
+		if s == "" || strings.ContainsAny(s, " \t,:=") {
+			return fmt.Errorf("invalid status %q: statuses can't be empty or contain spaces, ',', ':' or '='", s)
+		}
+		if seen[def.Status] {
+			return fmt.Errorf("status %q is listed twice", s)
+		}
+		if def.Limit < 0 {
+			return fmt.Errorf("WIP limit of %q can't be negative", s)
+		}
+		seen[def.Status] = true
+	}
+
+	if !seen[w.Done] {
+		return fmt.Errorf("done status %q is not one of the workflow's statuses", w.Done)
+	}
+
+	for from, targets := range w.Transitions {
+		if !seen[from] {
+			return fmt.Errorf("transition from unknown status %q", from)
+		}
+		for _, to := range targets {
+			if !seen[to] {
+				return fmt.Errorf("transition from %q to unknown status %q", from, to)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Index returns the position of the status in the workflow, or -1
+func (w *Workflow) Index(s Status) int {
+	for i, def := range w.Statuses {
+		if def.Status == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// Has reports whether the status belongs to the workflow
+func (w *Workflow) Has(s Status) bool {
+	return w.Index(s) >= 0
+}
+
+// Initial returns the status new items start in
+func (w *Workflow) Initial() Status {
+	return w.Statuses[0].Status
+}
+
+// Label returns the display name of the status
+func (w *Workflow) Label(s Status) string {
+	if i := w.Index(s); i >= 0 {
+		return w.Statuses[i].Label()
+	}
+	return string(s)
+}
+
+// StatusList returns the statuses as a comma-separated list for messages
+func (w *Workflow) StatusList() string {
+	names := make([]string, len(w.Statuses))
+	for i, def := range w.Statuses {
+		names[i] = string(def.Status)
+	}
+	return strings.Join(names, ", ")
+}
+
+// CheckStatus returns an error naming the valid statuses if s isn't one
+func (w *Workflow) CheckStatus(s Status) error {
+	if !w.Has(s) {
+		return fmt.Errorf("invalid status %q. Use: %s", s, w.StatusList())
+	}
+	return nil
+}
+
+// CheckMove returns an error if the workflow doesn't allow moving an item
+// from one status to another. Items in a status the workflow no longer
+// has may move anywhere.
+func (w *Workflow) CheckMove(from, to Status) error {
+	if err := w.CheckStatus(to); err != nil {
+		return err
+	}
+	targets, ok := w.Transitions[from]
+	if from == to || !ok {
+		return nil
+	}
+	for _, t := range targets {
+		if t == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("can't move from %s to %s (allowed: %s)", from, to, JoinStatuses(targets))
+}
+
+// Limit returns the WIP limit of the status, 0 meaning none
+func (w *Workflow) Limit(s Status) int {
+	if i := w.Index(s); i >= 0 {
+		return w.Statuses[i].Limit
+	}
+	return 0
+}
+
+// WIPLimitError is returned by CheckLimit when a status has no room for
+// another item
+type WIPLimitError struct {
+	Status Status
+	Limit  int
+	Count  int // items in the status before the move
+}
+
+func (e *WIPLimitError) Error() string {
+	return fmt.Sprintf("moving it would put %s over its WIP limit (%d/%d)", e.Status, e.Count+1, e.Limit)
+}
+
+// CheckLimit returns a *WIPLimitError if moving another item into status
+// would exceed its WIP limit, given the items currently on the board
+func (w *Workflow) CheckLimit(items []BacklogItem, status Status) error {
+	limit := w.Limit(status)
+	if limit == 0 {
+		return nil
+	}
+	count := CountStatuses(items)[status]
+	if count+1 > limit {
+		return &WIPLimitError{Status: status, Limit: limit, Count: count}
+	}
+	return nil
+}
+
+// CountStatuses returns how many of the items are in each status
+func CountStatuses(items []BacklogItem) map[Status]int {
+	counts := map[Status]int{}
+	for _, item := range items {
+		counts[item.Status]++
+	}
+	return counts
+}
+
+// Columns returns the statuses to show on a board holding items: the
+// workflow's own, followed by any other status an item still has, such
+// as one removed from the workflow after the item was moved there
+func (w *Workflow) Columns(items []BacklogItem) []StatusDef {
+	columns := append([]StatusDef{}, w.Statuses...)
+	for _, item := range items {
+		known := false
+		for _, def := range columns {
+			if def.Status == item.Status {
+				known = true
+				break
+			}
+		}
+		if !known {
+			columns = append(columns, StatusDef{Status: item.Status})
+		}
+	}
+	return columns
+}
+
+// JoinStatuses renders statuses as a comma-separated list, or "none"
+func JoinStatuses(statuses []Status) string {
+	if len(statuses) == 0 {
+		return "none"
+	}
+	names := make([]string, len(statuses))
+	for i, s := range statuses {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ", ")
+}
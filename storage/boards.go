@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+const (
+	// DefaultBoard is the board used when none is selected. It lives at
+	// the root of the data directory so existing backlogs keep working.
+	DefaultBoard = "default"
+
+	boardsDir = "boards"
+)
+
+// ErrBoardNotFound is returned when a named board doesn't exist
+var ErrBoardNotFound = errors.New("board not found")
+
+var boardNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// DataDir returns the directory holding all boards: $BACKLOG_DIR when set,
+// otherwise ~/backlog
+func DataDir() (string, error) {
+	if dir := os.Getenv("BACKLOG_DIR"); dir != "" {
+		return dir, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, backlogDir), nil
+}
+
+// BoardDir returns the directory holding the files of the named board
+func BoardDir(dataDir, name string) string {
+	if name == DefaultBoard {
+		return dataDir
+	}
+	return filepath.Join(dataDir, boardsDir, name)
+}
+
+// ValidBoardName checks that a board name is usable as a directory name
+func ValidBoardName(name string) bool {
+	return boardNamePattern.MatchString(name)
+}
+
+// BoardExists reports whether the named board has been created
+func BoardExists(dataDir, name string) bool {
+	if name == DefaultBoard {
+		return true
+	}
+	info, err := os.Stat(BoardDir(dataDir, name))
+	return err == nil && info.IsDir()
+}
+
+// ListBoards returns the names of all boards, default first
+func ListBoards(dataDir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dataDir, boardsDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read boards directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && ValidBoardName(entry.Name()) && entry.Name() != DefaultBoard {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	return append([]string{DefaultBoard}, names...), nil
+}
+
+// CreateBoard creates an empty board
+func CreateBoard(dataDir, name string) error {
+	if !ValidBoardName(name) {
+		return fmt.Errorf("invalid board name %q: use letters, digits, '-' and '_'", name)
+	}
+	if BoardExists(dataDir, name) {
+		return fmt.Errorf("board %q already exists", name)
+	}
+
+	if err := os.MkdirAll(BoardDir(dataDir, name), 0755); err != nil {
+		return fmt.Errorf("failed to create board directory: %w", err)
+	}
+	return nil
+}
+
+// RenameBoard renames a board. The default board can't be renamed.
+func RenameBoard(dataDir, oldName, newName string) error {
+	if oldName == DefaultBoard {
+		return fmt.Errorf("the %q board can't be renamed", DefaultBoard)
+	}
+	if !BoardExists(dataDir, oldName) {
+		return fmt.Errorf("%w: %s", ErrBoardNotFound, oldName)
+	}
+	if !ValidBoardName(newName) {
+		return fmt.Errorf("invalid board name %q: use letters, digits, '-' and '_'", newName)
+	}
+	if BoardExists(dataDir, newName) {
+		return fmt.Errorf("board %q already exists", newName)
+	}
+
+	if err := os.Rename(BoardDir(dataDir, oldName), BoardDir(dataDir, newName)); err != nil {
+		return fmt.Errorf("failed to rename board: %w", err)
+	}
+	return nil
+}
+
+// DeleteBoard removes a board and all of its files. The default board
+// can't be deleted.
+func DeleteBoard(dataDir, name string) error {
+	if name == DefaultBoard {
+		return fmt.Errorf("the %q board can't be deleted", DefaultBoard)
+	}
+	if !BoardExists(dataDir, name) {
+		return fmt.Errorf("%w: %s", ErrBoardNotFound, name)
+	}
+
+	if err := os.RemoveAll(BoardDir(dataDir, name)); err != nil {
+		return fmt.Errorf("failed to delete board: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/vvb/backlog/models"
+)
+
+const configFile = "config.json"
+
+// Config holds settings shared by all boards in a data directory
+type Config struct {
+	DefaultBoard string `json:"default_board,omitempty"`
+}
+
+// LoadConfig reads the config from the data directory. A missing file
+// yields the zero Config.
+func LoadConfig(dataDir string) (*Config, error) {
+	var config Config
+
+	data, err := os.ReadFile(filepath.Join(dataDir, configFile))
+	if os.IsNotExist(err) {
+		return &config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	return &config, nil
+}
+
+// SaveConfig writes the config to the data directory
+func SaveConfig(dataDir string, config *Config) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backlog directory: %w", err)
+	}
+
+	if err := writeFileAtomic(filepath.Join(dataDir, configFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return nil
+}
+
+const boardConfigFile = "board.json"
+
+// Storage backends a board can use
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// BoardConfig holds the settings of a single board
+type BoardConfig struct {
+	// Backend is the storage backend holding the board's items. Empty
+	// means BackendJSON.
+	Backend string `json:"backend,omitempty"`
+
+	// AutoArchive, when set, archives old done items every time the
+	// board is opened
+	AutoArchive *AutoArchive `json:"auto_archive,omitempty"`
+
+	// Workflow, when set, replaces the default todo, in-progress, done
+	// workflow
+	Workflow *models.Workflow `json:"workflow,omitempty"`
+
+	// Views are the board's saved queries, see 'backlog view'
+	Views []View `json:"views,omitempty"`
+}
+
+// View is a saved query, with the order to show the matching items in
+type View struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+	Sort  string `json:"sort,omitempty"` // one of models.SortOrders; empty means rank
+}
+
+// FindView returns the view with the given name, or nil
+func (c *BoardConfig) FindView(name string) *View {
+	for i := range c.Views {
+		if c.Views[i].Name == name {
+			return &c.Views[i]
+		}
+	}
+	return nil
+}
+
+// BoardWorkflow returns the board's workflow, or the default one
+func (c *BoardConfig) BoardWorkflow() *models.Workflow {
+	if c.Workflow == nil {
+		return models.DefaultWorkflow()
+	}
+	return c.Workflow
+}
+
+// AutoArchive is a board's auto-archive policy
+type AutoArchive struct {
+	// OlderThan is how long ago an item must have been completed, such
+	// as "14d"
+	OlderThan string `json:"older_than"`
+	// Tags restricts the policy to items carrying all of these tags
+	Tags []string `json:"tags,omitempty"`
+}
+
+// LoadBoardConfig reads the config of the board stored in dir. A missing
+// file yields the zero BoardConfig.
+func LoadBoardConfig(dir string) (*BoardConfig, error) {
+	var config BoardConfig
+
+	data, err := os.ReadFile(filepath.Join(dir, boardConfigFile))
+	if os.IsNotExist(err) {
+		return &config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read board config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse board config: %w", err)
+	}
+	if config.Workflow != nil {
+		if err := config.Workflow.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid workflow in board config: %w", err)
+		}
+	}
+
+	return &config, nil
+}
+
+// SaveBoardConfig writes the config of the board stored in dir
+func SaveBoardConfig(dir string, config *BoardConfig) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal board config: %w", err)
+	}
+
+	if err := writeFileAtomic(filepath.Join(dir, boardConfigFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write board config: %w", err)
+	}
+
+	return nil
+}
+
+// OpenBoard opens the board stored in dir with the backend named in its
+// config
+func OpenBoard(dir string) (Store, error) {
+	config, err := LoadBoardConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	switch config.Backend {
+	case "", BackendJSON:
+		return Open(dir)
+	case BackendSQLite:
+		return OpenSQLite(dir)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q in board config", config.Backend)
+	}
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/vvb/backlog/models"
+)
+
+// ConvertResult describes a finished ConvertBoard run
+type ConvertResult struct {
+	From     string
+	To       string
+	Items    int
+	Archived int
+	Trashed  int
+	// Backups lists the files of the old backend, renamed with a .bak
+	// suffix
+	Backups []string
+}
+
+// ConvertBoard copies the board stored in dir to another backend, checks
+// that everything reads back identically, switches the board config over
+// and moves the old backend's files aside
+func ConvertBoard(dir, backend string) (*ConvertResult, error) {
+	if backend != BackendJSON && backend != BackendSQLite {
+		return nil, fmt.Errorf("unknown storage backend %q (use %s or %s)", backend, BackendJSON, BackendSQLite)
+	}
+
+	config, err := LoadBoardConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	from := config.Backend
+	if from == "" {
+		from = BackendJSON
+	}
+	if from == backend {
+		return nil, fmt.Errorf("board already uses the %s backend", backend)
+	}
+
+	src, err := OpenBoard(dir)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := src.(interface{ Close() error }); ok {
+		defer closer.Close()
+	}
+
+	// Both backends share the board lock, so this keeps other processes
+	// out for the whole conversion
+	unlock, err := src.Lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	backlog, err := src.Load()
+	if err != nil {
+		return nil, err
+	}
+	archive, err := src.LoadArchive()
+	if err != nil {
+		return nil, err
+	}
+	trash, err := src.LoadTrash()
+	if err != nil {
+		return nil, err
+	}
+
+	var dst Store
+	var oldFiles, newFiles []string
+	switch backend {
+	case BackendSQLite:
+		if fileExists(filepath.Join(dir, sqliteFile)) {
+			return nil, fmt.Errorf("%s already exists; move it away before converting", sqliteFile)
+		}
+		db, err := OpenSQLite(dir)
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		dst = db
+		oldFiles = []string{backlogFile, archiveFile, trashFile}
+		newFiles = []string{sqliteFile}
+
+	case BackendJSON:
+		for _, name := range []string{backlogFile, archiveFile, trashFile} {
+			if fileExists(filepath.Join(dir, name)) {
+				return nil, fmt.Errorf("%s already exists; move it away before converting", name)
+			}
+		}
+		if dst, err = Open(dir); err != nil {
+			return nil, err
+		}
+		oldFiles = []string{sqliteFile}
+		newFiles = []string{backlogFile, archiveFile, trashFile}
+	}
+
+	// Don't leave a half-written copy behind if anything below fails
+	committed := false
+	defer func() {
+		if !committed {
+			for _, name := range newFiles {
+				os.Remove(filepath.Join(dir, name))
+			}
+		}
+	}()
+
+	if err := dst.Save(backlog); err != nil {
+		return nil, err
+	}
+	if err := dst.SaveArchive(archive); err != nil {
+		return nil, err
+	}
+	if err := dst.SaveTrash(trash); err != nil {
+		return nil, err
+	}
+
+	// Verify the copy before the board is switched over
+	if err := verifySame(backlog, dst.Load, "items"); err != nil {
+		return nil, err
+	}
+	if err := verifySame(archive, dst.LoadArchive, "archive"); err != nil {
+		return nil, err
+	}
+	if err := verifySame(trash, dst.LoadTrash, "trash"); err != nil {
+		return nil, err
+	}
+
+	config.Backend = backend
+	if err := SaveBoardConfig(dir, config); err != nil {
+		return nil, err
+	}
+	committed = true
+
+	result := &ConvertResult{
+		From:     from,
+		To:       backend,
+		Items:    len(backlog.Items),
+		Archived: len(archive.Items),
+		Trashed:  len(trash.Items),
+	}
+
+	if closer, ok := src.(interface{ Close() error }); ok {
+		closer.Close()
+	}
+	for _, name := range oldFiles {
+		path := filepath.Join(dir, name)
+		if !fileExists(path) {
+			continue
+		}
+		if err := os.Rename(path, path+".bak"); err != nil {
+			return nil, fmt.Errorf("converted, but failed to move %s aside: %w", name, err)
+		}
+		result.Backups = append(result.Backups, path+".bak")
+	}
+
+	return result, nil
+}
+
+// verifySame reloads data from the new backend and compares its JSON form
+// with what was written
+func verifySame(want *models.Backlog, load func() (*models.Backlog, error), what string) error {
+	got, err := load()
+	if err != nil {
+		return err
+	}
+
+	wantData, err := json.Marshal(want)
+	if err != nil {
+		return err
+	}
+	gotData, err := json.Marshal(got)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(wantData, gotData) {
+		return fmt.Errorf("converted %s do not match the original; the board was left unchanged", what)
+	}
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
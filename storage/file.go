@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const lockFileName = ".lock"
+
+// fingerprint identifies the contents of a data file as they were when it
+// was last read or written by this process
+type fingerprint struct {
+	exists bool
+	sum    [sha256.Size]byte
+}
+
+// readFingerprinted reads a data file and returns its contents together
+// with their fingerprint. A missing file yields nil data and no error.
+func readFingerprinted(path string) ([]byte, fingerprint, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fingerprint{}, nil
+	}
+	if err != nil {
+		return nil, fingerprint{}, err
+	}
+	return data, fingerprintOf(data), nil
+}
+
+// fingerprintOf returns the fingerprint of a file holding data
+func fingerprintOf(data []byte) fingerprint {
+	return fingerprint{exists: true, sum: sha256.Sum256(data)}
+}
+
+// writeFileAtomic replaces path with data without ever leaving a partially
+// written file behind: the data goes to a temp file in the same directory,
+// is fsynced, and is then renamed over the original.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	// Clean up the temp file on any failure before the rename
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	committed = true
+
+	return syncDir(dir)
+}
+
+// dirLock is a reentrant advisory lock on a data directory, shared by all
+// processes that use the same directory
+type dirLock struct {
+	mu    sync.Mutex
+	path  string
+	file  *os.File
+	depth int
+}
+
+var (
+	dirLocksMu sync.Mutex
+	dirLocks   = map[string]*dirLock{}
+)
+
+// dirLockFor returns the lock of a directory. Stores opened on the same
+// directory share one lock, since a second flock from the same process
+// would otherwise wait on the first forever.
+func dirLockFor(dir string) *dirLock {
+	path := filepath.Join(dir, lockFileName)
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	dirLocksMu.Lock()
+	defer dirLocksMu.Unlock()
+
+	if l, ok := dirLocks[path]; ok {
+		return l
+	}
+	l := &dirLock{path: path}
+	dirLocks[path] = l
+	return l
+}
+
+// acquire takes the lock, or increments the hold count when this process
+// already holds it. The returned function releases one hold.
+func (l *dirLock) acquire() (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.depth == 0 {
+		f, err := os.OpenFile(l.path, os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open lock file: %w", err)
+		}
+		if err := lockFile(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock backlog directory: %w", err)
+		}
+		l.file = f
+	}
+	l.depth++
+
+	released := false
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if released {
+			return
+		}
+		released = true
+
+		l.depth--
+		if l.depth == 0 {
+			unlockFile(l.file)
+			l.file.Close()
+			l.file = nil
+		}
+	}, nil
+}
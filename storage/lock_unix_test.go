@@ -0,0 +1,61 @@
+//go:build unix
+
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// tryLock reports whether another open file description can lock the
+// lock file of dir, as another process would
+func tryLock(t *testing.T, dir string) bool {
+	t.Helper()
+	f, err := os.OpenFile(filepath.Join(dir, lockFileName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	return true
+}
+
+func TestLockExcludesOtherProcesses(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := store.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nested, err := store.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tryLock(t, dir) {
+		t.Fatal("the directory could be locked while the store held it")
+	}
+
+	nested()
+	if tryLock(t, dir) {
+		t.Fatal("releasing a nested hold released the lock")
+	}
+	unlock()
+	if !tryLock(t, dir) {
+		t.Error("the lock was still held after the last release")
+	}
+}
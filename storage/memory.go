@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/vvb/backlog/models"
+)
+
+// MemoryStore is a Store backend that keeps everything in memory. It is
+// intended for tests and never touches the filesystem.
+type MemoryStore struct {
+	txMu    sync.Mutex
+	mu      sync.Mutex
+	backlog models.Backlog
+	archive models.Backlog
+	trash   models.Backlog
+}
+
+// NewMemory creates an empty MemoryStore
+func NewMemory() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Lock serializes read-modify-write cycles between goroutines
+func (s *MemoryStore) Lock() (func(), error) {
+	s.txMu.Lock()
+	return s.txMu.Unlock, nil
+}
+
+// Load returns a copy of the active backlog
+func (s *MemoryStore) Load() (*models.Backlog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return cloneBacklog(&s.backlog), nil
+}
+
+// Save replaces the active backlog with a copy of backlog, numbering new
+// items
+func (s *MemoryStore) Save(backlog *models.Backlog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	backlog.AssignNumbers()
+	s.backlog = *cloneBacklog(backlog)
+	return nil
+}
+
+// LoadArchive returns a copy of the archived items
+func (s *MemoryStore) LoadArchive() (*models.Backlog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return cloneBacklog(&s.archive), nil
+}
+
+// SaveArchive replaces the archived items with a copy of backlog
+func (s *MemoryStore) SaveArchive(backlog *models.Backlog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.archive = *cloneBacklog(backlog)
+	return nil
+}
+
+// LoadTrash returns a copy of the deleted items
+func (s *MemoryStore) LoadTrash() (*models.Backlog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return cloneBacklog(&s.trash), nil
+}
+
+// SaveTrash replaces the deleted items with a copy of backlog
+func (s *MemoryStore) SaveTrash(backlog *models.Backlog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trash = *cloneBacklog(backlog)
+	return nil
+}
+
+// Get returns a copy of the active item with the given ID
+func (s *MemoryStore) Get(id string) (*models.BacklogItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := findItem(&s.backlog, id)
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+
+	item := cloneItem(s.backlog.Items[i])
+	return &item, nil
+}
+
+// Put inserts the item, or replaces the active item with the same ID
+func (s *MemoryStore) Put(item models.BacklogItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item = cloneItem(item)
+	if item.Number == 0 {
+		item.Number = s.backlog.TakeNumber()
+	}
+	if i := findItem(&s.backlog, item.ID); i >= 0 {
+		s.backlog.Items[i] = item
+	} else {
+		s.backlog.Items = append(s.backlog.Items, item)
+	}
+	return nil
+}
+
+// Delete removes the active item with the given ID
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := findItem(&s.backlog, id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.backlog.Items = append(s.backlog.Items[:i], s.backlog.Items[i+1:]...)
+	return nil
+}
+
+// cloneBacklog deep-copies a backlog so callers can't mutate stored state
+// without going through Save
+func cloneBacklog(backlog *models.Backlog) *models.Backlog {
+	clone := *backlog
+	clone.Items = make([]models.BacklogItem, len(backlog.Items))
+	for i, item := range backlog.Items {
+		clone.Items[i] = cloneItem(item)
+	}
+	return &clone
+}
+
+func cloneItem(item models.BacklogItem) models.BacklogItem {
+	if item.Tags != nil {
+		item.Tags = append([]string(nil), item.Tags...)
+	}
+	if item.History != nil {
+		item.History = append([]models.Change(nil), item.History...)
+	}
+	if item.BlockedBy != nil {
+		item.BlockedBy = append([]string(nil), item.BlockedBy...)
+	}
+	if item.Notes != nil {
+		item.Notes = append([]models.Note(nil), item.Notes...)
+	}
+	if item.Checklist != nil {
+		item.Checklist = append([]models.ChecklistEntry(nil), item.Checklist...)
+	}
+	if item.TimeLog != nil {
+		item.TimeLog = append([]models.TimeEntry(nil), item.TimeLog...)
+	}
+	if item.TimerStarted != nil {
+		timerStarted := *item.TimerStarted
+		item.TimerStarted = &timerStarted
+	}
+	if item.DeletedAt != nil {
+		deletedAt := *item.DeletedAt
+		item.DeletedAt = &deletedAt
+	}
+	if item.UnarchivedAt != nil {
+		unarchivedAt := *item.UnarchivedAt
+		item.UnarchivedAt = &unarchivedAt
+	}
+	return item
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/vvb/backlog/filter"
+	"github.com/vvb/backlog/models"
+)
+
+// Query selects items. Zero-valued fields match everything.
+type Query struct {
+	// Filter is a parsed query (see the filter package) items must match
+	Filter filter.Expr
+	// Keywords restricts results to items whose title, description or tags
+	// contain all of these words, ignoring case
+	Keywords []string
+	// Statuses restricts results to items in any of these statuses
+	Statuses []models.Status
+	// Tags restricts results to items carrying all of these tags
+	Tags []string
+}
+
+// WithFilter returns q further restricted by expr. The terms ANDed at the
+// top of expr that backends can evaluate natively, words and phrases,
+// status: and single tag: terms, become Keywords, Statuses and Tags, and
+// only the rest is kept in Filter to be matched against each item.
+func (q Query) WithFilter(expr filter.Expr) Query {
+	q.Keywords = slices.Clip(q.Keywords)
+	q.Statuses = slices.Clip(q.Statuses)
+	q.Tags = slices.Clip(q.Tags)
+
+	var rest filter.And
+	if q.Filter != nil {
+		rest = append(rest, q.Filter)
+	}
+	for _, term := range conjuncts(expr) {
+		switch term := term.(type) {
+		case filter.Text:
+			q.Keywords = append(q.Keywords, term.Value)
+			continue
+
+		case filter.Field:
+			// Statuses are alternatives, so only a first list of them can
+			// be pushed down; tags are all required, so only single ones
+			switch {
+			case term.Op != filter.OpEq:
+			case term.Name == "status" && len(q.Statuses) == 0:
+				for _, value := range term.Values {
+					q.Statuses = append(q.Statuses, models.Status(value))
+				}
+				continue
+			case term.Name == "tag" && len(term.Values) == 1:
+				q.Tags = append(q.Tags, term.Values[0])
+				continue
+			}
+		}
+		rest = append(rest, term)
+	}
+
+	switch len(rest) {
+	case 0:
+		q.Filter = nil
+	case 1:
+		q.Filter = rest[0]
+	default:
+		q.Filter = rest
+	}
+	return q
+}
+
+// conjuncts returns the terms expr requires all of, looking through nested
+// Ands
+func conjuncts(expr filter.Expr) []filter.Expr {
+	and, ok := expr.(filter.And)
+	if !ok {
+		if expr == nil {
+			return nil
+		}
+		return []filter.Expr{expr}
+	}
+	var terms []filter.Expr
+	for _, x := range and {
+		terms = append(terms, conjuncts(x)...)
+	}
+	return terms
+}
+
+// Querier is implemented by backends that can evaluate a Query natively
+// instead of loading and scanning the whole backlog
+type Querier interface {
+	Query(q Query) ([]models.BacklogItem, error)
+}
+
+// Find returns the active items matching q in backlog order, pushing the
+// filtering down into the backend when it supports it
+func Find(s Store, q Query) ([]models.BacklogItem, error) {
+	if querier, ok := s.(Querier); ok {
+		return querier.Query(q)
+	}
+
+	backlog, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []models.BacklogItem{}
+	for _, item := range backlog.Items {
+		if q.Matches(item) {
+			matches = append(matches, item)
+		}
+	}
+	return matches, nil
+}
+
+// FindArchived returns the archived items matching q in archive order
+func FindArchived(s Store, q Query) ([]models.BacklogItem, error) {
+	archive, err := s.LoadArchive()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []models.BacklogItem{}
+	for _, item := range archive.Items {
+		if q.Matches(item) {
+			matches = append(matches, item)
+		}
+	}
+	return matches, nil
+}
+
+// Matches reports whether item satisfies the query
+func (q Query) Matches(item models.BacklogItem) bool {
+	if len(q.Statuses) > 0 {
+		found := false
+		for _, status := range q.Statuses {
+			if strings.EqualFold(string(item.Status), string(status)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, want := range q.Tags {
+		found := false
+		for _, tag := range item.Tags {
+			if strings.EqualFold(tag, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, keyword := range q.Keywords {
+		if !(filter.Text{Value: keyword}).Match(item) {
+			return false
+		}
+	}
+
+	if q.Filter != nil && !q.Filter.Match(item) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/vvb/backlog/filter"
+	"github.com/vvb/backlog/models"
+)
+
+func TestWithFilterPushesDownTopLevelTerms(t *testing.T) {
+	expr, err := filter.Parse(`login "sign in" status:todo,in-progress tag:api -tag:wontfix tag:a,b due<2026-12-01`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := Query{Tags: []string{"web"}}.WithFilter(expr)
+
+	if want := []string{"login", "sign in"}; !slices.Equal(q.Keywords, want) {
+		t.Errorf("Keywords = %q, want %q", q.Keywords, want)
+	}
+	if want := []models.Status{"todo", "in-progress"}; !slices.Equal(q.Statuses, want) {
+		t.Errorf("Statuses = %q, want %q", q.Statuses, want)
+	}
+	if want := []string{"web", "api"}; !slices.Equal(q.Tags, want) {
+		t.Errorf("Tags = %q, want %q", q.Tags, want)
+	}
+	rest, ok := q.Filter.(filter.And)
+	if !ok || len(rest) != 3 {
+		t.Fatalf("Filter = %#v, want the negation, the tag alternatives and the date", q.Filter)
+	}
+	if _, ok := rest[0].(filter.Not); !ok {
+		t.Errorf("Filter[0] = %#v, want the negated tag", rest[0])
+	}
+}
+
+func TestWithFilterKeepsStatusesOfTheQuery(t *testing.T) {
+	expr, err := filter.Parse("status:done")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := Query{Statuses: []models.Status{"todo"}}.WithFilter(expr)
+	if !slices.Equal(q.Statuses, []models.Status{"todo"}) || q.Filter == nil {
+		t.Errorf("query = %+v, want status:done left in the filter", q)
+	}
+}
+
+func TestWithFilterLeavesAlternativesAlone(t *testing.T) {
+	expr, err := filter.Parse("login OR status:todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := Query{}.WithFilter(expr)
+	if len(q.Keywords) != 0 || len(q.Statuses) != 0 {
+		t.Errorf("query = %+v, want nothing pushed down out of an OR", q)
+	}
+	if _, ok := q.Filter.(filter.Or); !ok {
+		t.Errorf("Filter = %#v, want the OR", q.Filter)
+	}
+}
+
+// TestFindMatchesAcrossBackends checks the SQL the SQLite backend pushes
+// down finds the same items as matching them in Go
+func TestFindMatchesAcrossBackends(t *testing.T) {
+	items := []models.BacklogItem{
+		{ID: "1", Title: "Fix LOGIN page", Status: models.StatusTodo, Tags: []string{"API"}},
+		{ID: "2", Title: "Write docs", Description: "explain login", Status: models.StatusInProgress, Tags: []string{"docs"}},
+		{ID: "3", Title: "Ship it", Status: models.StatusDone, Tags: []string{"api", "wontfix"}},
+		{ID: "4", Title: "Größe prüfen", Status: models.StatusTodo, Tags: []string{"Übersetzung"}},
+	}
+
+	sqlite, err := OpenSQLite(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close()
+	memory := NewMemory()
+	for _, store := range []Store{sqlite, memory} {
+		if _, err := store.Load(); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Save(&models.Backlog{Items: items}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"login", []string{"1", "2"}},
+		{"status:TODO", []string{"1", "4"}},
+		{"tag:api", []string{"1", "3"}},
+		{"tag:api -tag:wontfix", []string{"1"}},
+		{"LOGIN status:todo,in-progress", []string{"1", "2"}},
+		{"größe", []string{"4"}},
+		{"tag:übersetzung", []string{"4"}},
+		{"ship OR docs", []string{"2", "3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			expr, err := filter.Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q := Query{}.WithFilter(expr)
+			for name, store := range map[string]Store{"sqlite": sqlite, "memory": memory} {
+				found, err := Find(store, q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var ids []string
+				for _, item := range found {
+					ids = append(ids, item.ID)
+				}
+				if !slices.Equal(ids, tt.want) {
+					t.Errorf("%s found %q, want %q", name, ids, tt.want)
+				}
+			}
+		})
+	}
+}
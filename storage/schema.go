@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// SchemaVersion is the version of the on-disk format written by this
+// build. Bump it together with a new entry in migrations whenever the
+// format changes in a way older builds can't read back faithfully.
+const SchemaVersion = 14
+
+// ErrNewerSchema is returned when a file was written by a newer build
+var ErrNewerSchema = errors.New("file was written by a newer version of backlog")
+
+// document is a raw backlog file (items, archive, trash) as decoded JSON,
+// which migrations edit in place
+type document map[string]any
+
+// collection tells migrations which part of a board a document holds
+type collection string
+
+const (
+	collectionItems   collection = "items"
+	collectionArchive collection = "archive"
+	collectionTrash   collection = "trash"
+)
+
+// migration upgrades a document from version To-1 to version To
+type migration struct {
+	To          int
+	Description string
+	Up          func(doc document, kind collection) error
+}
+
+// migrations is the registry of schema upgrades, applied in order on load.
+// Never edit an entry once released; add a new one instead.
+var migrations = []migration{
+	{
+		To:          1,
+		Description: "add schema version",
+		Up:          func(doc document, kind collection) error { return nil },
+	},
+	{
+		To:          2,
+		Description: "add item history",
+		Up:          addsFields,
+	},
+	{
+		To:          3,
+		Description: "add trash deletion times",
+		Up:          addsFields,
+	},
+	{
+		To:          4,
+		Description: "number active items",
+		Up:          numberItems,
+	},
+	{
+		To:          5,
+		Description: "add item priorities",
+		Up:          addsFields,
+	},
+	{
+		To:          6,
+		Description: "add manual ranks",
+		Up:          addsFields,
+	},
+	{
+		To:          7,
+		Description: "allow workflow statuses",
+		Up:          addsFields,
+	},
+	{
+		To:          8,
+		Description: "add checklists",
+		Up:          addsFields,
+	},
+	{
+		To:          9,
+		Description: "add parent epics",
+		Up:          addsFields,
+	},
+	{
+		To:          10,
+		Description: "add blocking links",
+		Up:          addsFields,
+	},
+	{
+		To:          11,
+		Description: "add notes",
+		Up:          addsFields,
+	},
+	{
+		To:          12,
+		Description: "add time logs and timers",
+		Up:          addsFields,
+	},
+	{
+		To:          13,
+		Description: "add recurrence rules",
+		Up:          addsFields,
+	},
+	{
+		To:          14,
+		Description: "add unarchive times",
+		Up:          addsFields,
+	},
+}
+
+func init() {
+	// Keep the registry and SchemaVersion in step
+	for i, m := range migrations {
+		if m.To != i+1 {
+			panic(fmt.Sprintf("storage: migration %d upgrades to version %d", i, m.To))
+		}
+	}
+	if len(migrations) != SchemaVersion {
+		panic("storage: SchemaVersion doesn't match the migration registry")
+	}
+}
+
+// documentVersion returns the schema version recorded in a raw file
+func documentVersion(data []byte) (int, error) {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, err
+	}
+	return header.Version, nil
+}
+
+// checkVersion refuses files written by a newer schema
+func checkVersion(version int, name string) error {
+	if version > SchemaVersion {
+		return fmt.Errorf("%w: %s uses schema version %d, but this build only supports up to %d; please upgrade backlog",
+			ErrNewerSchema, name, version, SchemaVersion)
+	}
+	return nil
+}
+
+// upgradeDocument runs every migration newer than the document's version
+// and returns the re-encoded result
+func upgradeDocument(data []byte, name string, kind collection) ([]byte, error) {
+	version, err := documentVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(version, name); err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc document
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	for _, m := range migrations[version:] {
+		if err := m.Up(doc, kind); err != nil {
+			return nil, fmt.Errorf("failed to upgrade %s to schema version %d (%s): %w", name, m.To, m.Description, err)
+		}
+		doc["version"] = m.To
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// backupFile copies path to a sibling file named after the schema version
+// it holds, so an upgrade can always be rolled back by hand
+func backupFile(path string, version int) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(fmt.Sprintf("%s.v%d.bak", path, version), data, 0644)
+}
+
+// addsFields is the migration of a version that only adds optional item
+// fields. Older documents are already valid; the new version is what stops
+// older builds, which would drop the fields when saving, from loading them.
+func addsFields(doc document, kind collection) error {
+	return nil
+}
+
+// numberItems gives active items sequential numbers in order of creation
+// and starts the counter after them. Archived and trashed items keep
+// their internal IDs only, so numbers never collide.
+func numberItems(doc document, kind collection) error {
+	if kind != collectionItems {
+		return nil
+	}
+
+	raw, _ := doc["items"].([]any)
+	items := make([]map[string]any, 0, len(raw))
+	for _, entry := range raw {
+		item, ok := entry.(map[string]any)
+		if !ok {
+			return fmt.Errorf("unexpected item %v", entry)
+		}
+		items = append(items, item)
+	}
+
+	createdAt := func(item map[string]any) time.Time {
+		s, _ := item["created_at"].(string)
+		t, _ := time.Parse(time.RFC3339Nano, s)
+		return t
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return createdAt(items[i]).Before(createdAt(items[j]))
+	})
+
+	for i, item := range items {
+		item["number"] = i + 1
+	}
+	doc["next_number"] = len(items) + 1
+	return nil
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// unversioned is an items file written before the schema was versioned,
+// with items out of creation order
+const unversioned = `{
+  "items": [
+    {"id": "b", "title": "Second", "status": "todo", "created_at": "2025-01-02T00:00:00Z"},
+    {"id": "a", "title": "First", "status": "todo", "created_at": "2025-01-01T00:00:00Z"}
+  ]
+}`
+
+func TestUpgradeDocumentNumbersItems(t *testing.T) {
+	data, err := upgradeDocument([]byte(unversioned), backlogFile, collectionItems)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Version    int `json:"version"`
+		NextNumber int `json:"next_number"`
+		Items      []struct {
+			ID     string `json:"id"`
+			Number int    `json:"number"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Version != SchemaVersion {
+		t.Errorf("version = %d, want %d", doc.Version, SchemaVersion)
+	}
+	if doc.NextNumber != 3 {
+		t.Errorf("next_number = %d, want 3", doc.NextNumber)
+	}
+	numbers := map[string]int{}
+	for _, item := range doc.Items {
+		numbers[item.ID] = item.Number
+	}
+	if numbers["a"] != 1 || numbers["b"] != 2 {
+		t.Errorf("numbers = %v, want a:1 b:2 in order of creation", numbers)
+	}
+}
+
+func TestUpgradeDocumentLeavesArchiveUnnumbered(t *testing.T) {
+	data, err := upgradeDocument([]byte(unversioned), archiveFile, collectionArchive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc["next_number"]; ok {
+		t.Error("archive was given a next_number")
+	}
+}
+
+func TestUpgradeDocumentRefusesNewerSchema(t *testing.T) {
+	newer := fmt.Sprintf(`{"version": %d, "items": []}`, SchemaVersion+1)
+	_, err := upgradeDocument([]byte(newer), backlogFile, collectionItems)
+	if !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("err = %v, want ErrNewerSchema", err)
+	}
+}
+
+func TestJSONStoreUpgradesOnLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, backlogFile)
+	if err := os.WriteFile(path, []byte(unversioned), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backlog, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backlog.Version != SchemaVersion {
+		t.Errorf("loaded version = %d, want %d", backlog.Version, SchemaVersion)
+	}
+	for _, item := range backlog.Items {
+		if item.Number == 0 {
+			t.Errorf("item %s wasn't numbered", item.ID)
+		}
+	}
+
+	// The original is kept, and the upgraded file is written back
+	backup, err := os.ReadFile(path + ".v0.bak")
+	if err != nil {
+		t.Fatalf("no backup of the original: %v", err)
+	}
+	if string(backup) != unversioned {
+		t.Error("backup differs from the original file")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, err := documentVersion(data); err != nil || version != SchemaVersion {
+		t.Errorf("file on disk has version %d (%v), want %d", version, err, SchemaVersion)
+	}
+}
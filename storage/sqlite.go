@@ -0,0 +1,688 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vvb/backlog/models"
+	_ "modernc.org/sqlite"
+)
+
+const sqliteFile = "backlog.db"
+
+// Item tables share one layout. The indexed columns serve queries; data
+// holds the complete item as JSON so fields without a column of their own
+// still round-trip losslessly.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS items (
+	id          TEXT PRIMARY KEY,
+	position    INTEGER NOT NULL,
+	title       TEXT NOT NULL,
+	description TEXT NOT NULL,
+	due_date    TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	created_at  TEXT NOT NULL,
+	updated_at  TEXT NOT NULL,
+	data        TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS items_status ON items (status, position);
+CREATE INDEX IF NOT EXISTS items_position ON items (position);
+
+CREATE TABLE IF NOT EXISTS tags (
+	item_id TEXT NOT NULL REFERENCES items (id) ON DELETE CASCADE,
+	tag     TEXT NOT NULL COLLATE NOCASE,
+	PRIMARY KEY (item_id, tag)
+);
+CREATE INDEX IF NOT EXISTS tags_tag ON tags (tag);
+
+CREATE TABLE IF NOT EXISTS archive (
+	id          TEXT PRIMARY KEY,
+	position    INTEGER NOT NULL,
+	title       TEXT NOT NULL,
+	description TEXT NOT NULL,
+	due_date    TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	created_at  TEXT NOT NULL,
+	updated_at  TEXT NOT NULL,
+	data        TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS archive_position ON archive (position);
+
+CREATE TABLE IF NOT EXISTS trash (
+	id          TEXT PRIMARY KEY,
+	position    INTEGER NOT NULL,
+	title       TEXT NOT NULL,
+	description TEXT NOT NULL,
+	due_date    TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	created_at  TEXT NOT NULL,
+	updated_at  TEXT NOT NULL,
+	data        TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS trash_position ON trash (position);
+`
+
+// SQLiteStore is the Store backend that keeps the backlog in a SQLite
+// database, so single-item changes don't rewrite the whole backlog
+type SQLiteStore struct {
+	dir  string
+	db   *sql.DB
+	lock *dirLock
+
+	mu     sync.Mutex
+	loaded map[string]int64 // table -> revision as last seen
+}
+
+// OpenSQLite creates a SQLiteStore for the board stored in dir, creating
+// the database if needed
+func OpenSQLite(dir string) (*SQLiteStore, error) {
+	dsn := "file:" + filepath.ToSlash(filepath.Join(dir, sqliteFile)) + "?" + url.Values{
+		"_pragma": {"foreign_keys(1)", "busy_timeout(5000)"},
+	}.Encode()
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create database schema: %w", err)
+	}
+
+	s := &SQLiteStore{
+		dir:    dir,
+		db:     db,
+		lock:   dirLockFor(dir),
+		loaded: map[string]int64{},
+	}
+
+	if err := s.upgrade(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// upgrade migrates tables written by an older schema, after backing up
+// the database, and refuses databases written by a newer one
+func (s *SQLiteStore) upgrade() error {
+	unlock, err := s.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	backedUp := false
+	for _, table := range []string{"items", "archive", "trash"} {
+		data, version, err := s.rawDocument(table)
+		if err != nil {
+			return err
+		}
+		if data == nil || version == SchemaVersion {
+			continue
+		}
+		if err := checkVersion(version, sqliteFile); err != nil {
+			return err
+		}
+
+		if !backedUp {
+			backup := fmt.Sprintf("%s.v%d.bak", filepath.Join(s.dir, sqliteFile), version)
+			os.Remove(backup)
+			if _, err := s.db.Exec(`VACUUM INTO ?`, backup); err != nil {
+				return fmt.Errorf("failed to back up %s before upgrading it: %w", sqliteFile, err)
+			}
+			backedUp = true
+		}
+
+		upgraded, err := upgradeDocument(data, sqliteFile+" "+table, collection(table))
+		if err != nil {
+			return err
+		}
+		var backlog models.Backlog
+		if err := json.Unmarshal(upgraded, &backlog); err != nil {
+			return fmt.Errorf("failed to parse upgraded %s: %w", table, err)
+		}
+		if err := s.saveTable(table, &backlog); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rawDocument assembles an item table into the JSON document layout used
+// by the JSON backend, so both backends share one migration registry. It
+// returns nil for a table that has never been written.
+func (s *SQLiteStore) rawDocument(table string) ([]byte, int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	header, err := getMeta(tx, table+"_header")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := tx.Query(`SELECT data FROM ` + table + ` ORDER BY position`)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	items := []json.RawMessage{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, 0, fmt.Errorf("failed to read item: %w", err)
+		}
+		items = append(items, json.RawMessage(data))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+
+	if header == "" && len(items) == 0 {
+		return nil, 0, nil
+	}
+
+	doc := map[string]json.RawMessage{}
+	if header != "" {
+		if err := json.Unmarshal([]byte(header), &doc); err != nil {
+			return nil, 0, fmt.Errorf("failed to parse %s header: %w", table, err)
+		}
+	}
+	if doc["items"], err = json.Marshal(items); err != nil {
+		return nil, 0, err
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, 0, err
+	}
+	version, err := documentVersion(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse %s header: %w", table, err)
+	}
+	return data, version, nil
+}
+
+// Close releases the database handle
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// Lock takes the advisory lock on the board directory
+func (s *SQLiteStore) Lock() (func(), error) {
+	return s.lock.acquire()
+}
+
+// Load reads the active backlog
+func (s *SQLiteStore) Load() (*models.Backlog, error) {
+	return s.loadTable("items")
+}
+
+// Save replaces the active backlog, numbering new items
+func (s *SQLiteStore) Save(backlog *models.Backlog) error {
+	backlog.AssignNumbers()
+	return s.saveTable("items", backlog)
+}
+
+// LoadArchive reads the archived items
+func (s *SQLiteStore) LoadArchive() (*models.Backlog, error) {
+	return s.loadTable("archive")
+}
+
+// SaveArchive replaces the archived items
+func (s *SQLiteStore) SaveArchive(backlog *models.Backlog) error {
+	return s.saveTable("archive", backlog)
+}
+
+// LoadTrash reads the deleted items
+func (s *SQLiteStore) LoadTrash() (*models.Backlog, error) {
+	return s.loadTable("trash")
+}
+
+// SaveTrash replaces the deleted items
+func (s *SQLiteStore) SaveTrash(backlog *models.Backlog) error {
+	return s.saveTable("trash", backlog)
+}
+
+// Get returns the active item with the given ID
+func (s *SQLiteStore) Get(id string) (*models.BacklogItem, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM items WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read item: %w", err)
+	}
+
+	var item models.BacklogItem
+	if err := json.Unmarshal([]byte(data), &item); err != nil {
+		return nil, fmt.Errorf("failed to parse item %s: %w", id, err)
+	}
+	return &item, nil
+}
+
+// Put inserts the item at the end of the backlog, or replaces the active
+// item with the same ID in place
+func (s *SQLiteStore) Put(item models.BacklogItem) error {
+	return s.inTx("items", func(tx *sql.Tx) error {
+		var position int64
+		err := tx.QueryRow(`SELECT position FROM items WHERE id = ?`, item.ID).Scan(&position)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = tx.QueryRow(`SELECT COALESCE(MAX(position), -1) + 1 FROM items`).Scan(&position)
+		}
+		if err != nil {
+			return err
+		}
+
+		if item.Number == 0 {
+			if item.Number, err = takeNumber(tx); err != nil {
+				return err
+			}
+		}
+
+		return upsertItem(tx, "items", position, item)
+	})
+}
+
+// Delete removes the active item with the given ID
+func (s *SQLiteStore) Delete(id string) error {
+	return s.inTx("items", func(tx *sql.Tx) error {
+		res, err := tx.Exec(`DELETE FROM items WHERE id = ?`, id)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+// Query evaluates q in SQL, apart from its filter, which is applied to the
+// rows SQL selected
+func (s *SQLiteStore) Query(q Query) ([]models.BacklogItem, error) {
+	var where []string
+	var args []any
+
+	// SQLite only folds ASCII letters when ignoring case, so values with
+	// other letters are left to the check in Go below
+	pushStatuses := len(q.Statuses) > 0
+	for _, status := range q.Statuses {
+		pushStatuses = pushStatuses && isASCII(string(status))
+	}
+	if pushStatuses {
+		placeholders := make([]string, len(q.Statuses))
+		for i, status := range q.Statuses {
+			placeholders[i] = "?"
+			args = append(args, string(status))
+		}
+		where = append(where, "status COLLATE NOCASE IN ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	for _, tag := range q.Tags {
+		if !isASCII(tag) {
+			continue
+		}
+		where = append(where, "EXISTS (SELECT 1 FROM tags t WHERE t.item_id = items.id AND t.tag = ? COLLATE NOCASE)")
+		args = append(args, tag)
+	}
+
+	for _, keyword := range q.Keywords {
+		if !isASCII(keyword) {
+			continue
+		}
+		where = append(where, "(instr(lower(title), lower(?)) > 0 OR instr(lower(description), lower(?)) > 0"+
+			" OR EXISTS (SELECT 1 FROM tags t WHERE t.item_id = items.id AND instr(lower(t.tag), lower(?)) > 0))")
+		args = append(args, keyword, keyword, keyword)
+	}
+
+	query := `SELECT data FROM items`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY position"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query items: %w", err)
+	}
+	defer rows.Close()
+
+	// The rows are checked against the whole query, which covers the
+	// Filter and the values left out of the SQL
+	items, err := scanItems(rows)
+	if err != nil {
+		return nil, err
+	}
+	matches := []models.BacklogItem{}
+	for _, item := range items {
+		if q.Matches(item) {
+			matches = append(matches, item)
+		}
+	}
+	return matches, nil
+}
+
+// isASCII reports whether s is plain ASCII
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// loadTable reads all items of an item table in order, along with the
+// backlog-level fields kept in meta
+func (s *SQLiteStore) loadTable(table string) (*models.Backlog, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer tx.Rollback()
+
+	backlog := models.Backlog{}
+	header, err := getMeta(tx, table+"_header")
+	if err != nil {
+		return nil, err
+	}
+	if header != "" {
+		if err := json.Unmarshal([]byte(header), &backlog); err != nil {
+			return nil, fmt.Errorf("failed to parse %s header: %w", table, err)
+		}
+	}
+
+	revision, err := getRevision(tx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(`SELECT data FROM ` + table + ` ORDER BY position`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	backlog.Items, err = scanItems(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.loaded[table] = revision
+	s.mu.Unlock()
+
+	return &backlog, nil
+}
+
+// saveTable replaces the contents of an item table. Like the JSON backend
+// it refuses to overwrite changes made since the table was last loaded.
+func (s *SQLiteStore) saveTable(table string, backlog *models.Backlog) error {
+	backlog.Version = SchemaVersion
+	header := *backlog
+	header.Items = nil
+	headerData, err := json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", table, err)
+	}
+
+	return s.inTx(table, func(tx *sql.Tx) error {
+		s.mu.Lock()
+		seen, ok := s.loaded[table]
+		s.mu.Unlock()
+		if ok {
+			current, err := getRevision(tx, table)
+			if err != nil {
+				return err
+			}
+			if current != seen {
+				return fmt.Errorf("%w: %s was modified by another process since it was loaded", ErrConflict, table)
+			}
+		}
+
+		if err := setMeta(tx, table+"_header", string(headerData)); err != nil {
+			return err
+		}
+
+		// Drop rows that are no longer present, then write only the rows
+		// that changed, so saving after a small change stays cheap on a
+		// large board
+		keep := make(map[string]bool, len(backlog.Items))
+		for _, item := range backlog.Items {
+			keep[item.ID] = true
+		}
+		rows, err := tx.Query(`SELECT id, position, data FROM ` + table)
+		if err != nil {
+			return err
+		}
+		type row struct {
+			position int64
+			data     string
+		}
+		existing := map[string]row{}
+		var stale []string
+		for rows.Next() {
+			var id string
+			var r row
+			if err := rows.Scan(&id, &r.position, &r.data); err != nil {
+				rows.Close()
+				return err
+			}
+			if keep[id] {
+				existing[id] = r
+			} else {
+				stale = append(stale, id)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, id := range stale {
+			if _, err := tx.Exec(`DELETE FROM `+table+` WHERE id = ?`, id); err != nil {
+				return err
+			}
+		}
+
+		for i, item := range backlog.Items {
+			position := int64(i)
+			r, ok := existing[item.ID]
+			if ok {
+				data, err := json.Marshal(item)
+				if err != nil {
+					return err
+				}
+				if r.data == string(data) {
+					if r.position != position {
+						if _, err := tx.Exec(`UPDATE `+table+` SET position = ? WHERE id = ?`, position, item.ID); err != nil {
+							return err
+						}
+					}
+					continue
+				}
+			}
+			if err := upsertItem(tx, table, position, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// inTx runs fn in a transaction that bumps the revision of table, holding
+// the board lock so JSON-style read-modify-write cycles stay serialized
+func (s *SQLiteStore) inTx(table string, fn func(tx *sql.Tx) error) error {
+	unlock, err := s.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", table, err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrConflict) {
+			return err
+		}
+		return fmt.Errorf("failed to write %s: %w", table, err)
+	}
+
+	revision, err := getRevision(tx, table)
+	if err != nil {
+		return err
+	}
+	revision++
+	if err := setMeta(tx, table+"_revision", fmt.Sprint(revision)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", table, err)
+	}
+
+	s.mu.Lock()
+	s.loaded[table] = revision
+	s.mu.Unlock()
+	return nil
+}
+
+// takeNumber reserves the next item number, which lives in the header of
+// the items table
+func takeNumber(tx *sql.Tx) (int, error) {
+	header := models.Backlog{Version: SchemaVersion}
+	data, err := getMeta(tx, "items_header")
+	if err != nil {
+		return 0, err
+	}
+	if data != "" {
+		if err := json.Unmarshal([]byte(data), &header); err != nil {
+			return 0, fmt.Errorf("failed to parse items header: %w", err)
+		}
+	}
+
+	n := header.TakeNumber()
+	updated, err := json.Marshal(header)
+	if err != nil {
+		return 0, err
+	}
+	return n, setMeta(tx, "items_header", string(updated))
+}
+
+// upsertItem writes item at position into an item table, keeping the
+// tags table in sync for active items
+func upsertItem(tx *sql.Tx, table string, position int64, item models.BacklogItem) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO `+table+` (id, position, title, description, due_date, status, created_at, updated_at, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			position = excluded.position,
+			title = excluded.title,
+			description = excluded.description,
+			due_date = excluded.due_date,
+			status = excluded.status,
+			created_at = excluded.created_at,
+			updated_at = excluded.updated_at,
+			data = excluded.data`,
+		item.ID, position, item.Title, item.Description, item.DueDate, string(item.Status),
+		item.CreatedAt.Format(time.RFC3339Nano), item.UpdatedAt.Format(time.RFC3339Nano), string(data))
+	if err != nil {
+		return err
+	}
+
+	if table != "items" {
+		return nil
+	}
+
+	if _, err := tx.Exec(`DELETE FROM tags WHERE item_id = ?`, item.ID); err != nil {
+		return err
+	}
+	for _, tag := range item.Tags {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO tags (item_id, tag) VALUES (?, ?)`, item.ID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanItems(rows *sql.Rows) ([]models.BacklogItem, error) {
+	items := []models.BacklogItem{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read item: %w", err)
+		}
+
+		var item models.BacklogItem
+		if err := json.Unmarshal([]byte(data), &item); err != nil {
+			return nil, fmt.Errorf("failed to parse item: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read items: %w", err)
+	}
+	return items, nil
+}
+
+func getRevision(tx *sql.Tx, table string) (int64, error) {
+	value, err := getMeta(tx, table+"_revision")
+	if err != nil || value == "" {
+		return 0, err
+	}
+
+	var revision int64
+	if _, err := fmt.Sscan(value, &revision); err != nil {
+		return 0, fmt.Errorf("invalid %s revision %q", table, value)
+	}
+	return revision, nil
+}
+
+func getMeta(tx *sql.Tx, key string) (string, error) {
+	var value string
+	err := tx.QueryRow(`SELECT value FROM meta WHERE key = ?`, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return value, nil
+}
+
+func setMeta(tx *sql.Tx, key, value string) error {
+	_, err := tx.Exec(`INSERT INTO meta (key, value) VALUES (?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value`, key, value)
+	return err
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/vvb/backlog/models"
+)
+
+// totalChanges returns the number of rows the store's connection has
+// written since it was opened
+func totalChanges(t *testing.T, s *SQLiteStore) int {
+	t.Helper()
+	var n int
+	if err := s.db.QueryRow(`SELECT total_changes()`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestSQLiteSaveWritesOnlyChangedRows(t *testing.T) {
+	store, err := OpenSQLite(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	backlog, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		backlog.Items = append(backlog.Items, models.BacklogItem{
+			ID:     fmt.Sprintf("item-%d", i),
+			Title:  fmt.Sprintf("Item %d", i),
+			Status: models.StatusTodo,
+			Tags:   []string{"a", "b"},
+		})
+	}
+	if err := store.Save(backlog); err != nil {
+		t.Fatal(err)
+	}
+
+	// One changed item rewrites its row and tags, plus the header and the
+	// revision, instead of all 50 items
+	backlog, err = store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backlog.Items[10].Status = models.StatusDone
+	before := totalChanges(t, store)
+	if err := store.Save(backlog); err != nil {
+		t.Fatal(err)
+	}
+	if written := totalChanges(t, store) - before; written > 8 {
+		t.Errorf("saving one changed item wrote %d rows", written)
+	}
+
+	// Deleting an item only moves the rows after it up
+	backlog, err = store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backlog.Items = append(backlog.Items[:45], backlog.Items[46:]...)
+	before = totalChanges(t, store)
+	if err := store.Save(backlog); err != nil {
+		t.Fatal(err)
+	}
+	if written := totalChanges(t, store) - before; written > 12 {
+		t.Errorf("deleting one item near the end wrote %d rows", written)
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 49 || got.Items[10].Status != models.StatusDone || got.Items[45].ID != "item-46" {
+		t.Errorf("reloaded backlog doesn't match what was saved")
+	}
+}
+
+func TestConvertBoardRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	at := time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)
+	deleted := at.Add(48 * time.Hour)
+
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backlog, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backlog.Items = []models.BacklogItem{
+		{
+			ID: "a", Title: "Active", Description: "With history", Status: models.StatusInProgress,
+			Priority: models.PriorityP1, Tags: []string{"api"}, CreatedAt: at, UpdatedAt: at.Add(time.Hour),
+			History: []models.Change{{At: at.Add(time.Hour), Field: "status", Old: "todo", New: "in-progress"}},
+		},
+		{ID: "b", Title: "Second", Status: models.StatusTodo, DueDate: "15-11-2026", CreatedAt: at, UpdatedAt: at},
+	}
+	if err := store.Save(backlog); err != nil {
+		t.Fatal(err)
+	}
+	archive := &models.Backlog{Items: []models.BacklogItem{{ID: "c", Number: 7, Title: "Archived", Status: models.StatusDone, CreatedAt: at, UpdatedAt: at}}}
+	if err := store.SaveArchive(archive); err != nil {
+		t.Fatal(err)
+	}
+	trash := &models.Backlog{Items: []models.BacklogItem{{ID: "d", Number: 8, Title: "Trashed", Status: models.StatusTodo, CreatedAt: at, UpdatedAt: at, DeletedAt: &deleted}}}
+	if err := store.SaveTrash(trash); err != nil {
+		t.Fatal(err)
+	}
+
+	want := boardJSON(t, store)
+	if _, err := ConvertBoard(dir, BackendSQLite); err != nil {
+		t.Fatal(err)
+	}
+	sqlite, err := OpenBoard(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := boardJSON(t, sqlite); got != want {
+		t.Errorf("board after converting to SQLite:\n%s\nwant:\n%s", got, want)
+	}
+	sqlite.(*SQLiteStore).Close()
+
+	if _, err := ConvertBoard(dir, BackendJSON); err != nil {
+		t.Fatal(err)
+	}
+	jsonStore, err := OpenBoard(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := boardJSON(t, jsonStore); got != want {
+		t.Errorf("board after converting back to JSON:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Numbering carries on where it left off
+	backlog, err = jsonStore.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backlog.Items = append(backlog.Items, models.BacklogItem{ID: "e", Title: "New"})
+	if err := jsonStore.Save(backlog); err != nil {
+		t.Fatal(err)
+	}
+	if n := backlog.Items[2].Number; n != 3 {
+		t.Errorf("new item got number %d, want 3", n)
+	}
+}
+
+// boardJSON returns the items, archive and trash of a board as JSON
+func boardJSON(t *testing.T, s Store) string {
+	t.Helper()
+	var out string
+	for _, load := range []func() (*models.Backlog, error){s.Load, s.LoadArchive, s.LoadTrash} {
+		backlog, err := load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.MarshalIndent(backlog, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		out += string(data) + "\n"
+	}
+	return out
+}
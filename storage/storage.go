@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/vvb/backlog/models"
 )
@@ -13,102 +14,271 @@ const (
 	backlogDir  = "backlog"
 	backlogFile = "items.json"
 	archiveFile = "archive.json"
+	trashFile   = "trash.json"
 )
 
-// Storage handles reading and writing backlog data
-type Storage struct {
+// fileCollections maps each data file to the collection it holds
+var fileCollections = map[string]collection{
+	backlogFile: collectionItems,
+	archiveFile: collectionArchive,
+	trashFile:   collectionTrash,
+}
+
+// JSONStore is the Store backend that keeps the backlog in JSON files
+type JSONStore struct {
 	dataDir string
+	lock    *dirLock
+
+	mu     sync.Mutex
+	loaded map[string]fingerprint // file name -> contents as last seen
 }
 
-// New creates a new Storage instance
-func New() (*Storage, error) {
-	homeDir, err := os.UserHomeDir()
+// New creates a JSONStore for the default board in DataDir
+func New() (*JSONStore, error) {
+	dataDir, err := DataDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+		return nil, err
 	}
 
-	dataDir := filepath.Join(homeDir, backlogDir)
+	return Open(BoardDir(dataDir, DefaultBoard))
+}
 
+// Open creates a JSONStore for the board stored in dir
+func Open(dir string) (*JSONStore, error) {
 	// Create directory if it doesn't exist
-	if err := os.MkdirAll(dataDir, 0755); err != nil {
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create backlog directory: %w", err)
 	}
 
-	return &Storage{dataDir: dataDir}, nil
+	return &JSONStore{
+		dataDir: dir,
+		lock:    dirLockFor(dir),
+		loaded:  map[string]fingerprint{},
+	}, nil
+}
+
+// Lock takes the advisory lock on the data directory. Other processes
+// block in Lock (and in Save) until unlock is called.
+func (s *JSONStore) Lock() (func(), error) {
+	return s.lock.acquire()
 }
 
 // Load reads the backlog from the JSON file
-func (s *Storage) Load() (*models.Backlog, error) {
-	filePath := filepath.Join(s.dataDir, backlogFile)
+func (s *JSONStore) Load() (*models.Backlog, error) {
+	return s.readFile(backlogFile, "backlog")
+}
+
+// Save writes the backlog to the JSON file, numbering new items
+func (s *JSONStore) Save(backlog *models.Backlog) error {
+	backlog.AssignNumbers()
+	return s.writeFile(backlogFile, "backlog", backlog)
+}
+
+// LoadArchive reads the archived items from the JSON file
+func (s *JSONStore) LoadArchive() (*models.Backlog, error) {
+	return s.readFile(archiveFile, "archive")
+}
+
+// SaveArchive writes the archived items to the JSON file
+func (s *JSONStore) SaveArchive(backlog *models.Backlog) error {
+	return s.writeFile(archiveFile, "archive", backlog)
+}
+
+// LoadTrash reads the deleted items from the JSON file
+func (s *JSONStore) LoadTrash() (*models.Backlog, error) {
+	return s.readFile(trashFile, "trash")
+}
+
+// SaveTrash writes the deleted items to the JSON file
+func (s *JSONStore) SaveTrash(backlog *models.Backlog) error {
+	return s.writeFile(trashFile, "trash", backlog)
+}
+
+// readFile loads one of the data files and remembers its fingerprint so
+// that a later write can detect changes made by other processes
+func (s *JSONStore) readFile(name, what string) (*models.Backlog, error) {
+	filePath := filepath.Join(s.dataDir, name)
+
+	data, fp, err := readFingerprinted(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s file: %w", what, err)
+	}
 
 	// If file doesn't exist, return empty backlog
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return &models.Backlog{Items: []models.BacklogItem{}}, nil
+	if !fp.exists {
+		s.setLoaded(name, fp)
+		return &models.Backlog{Version: SchemaVersion, Items: []models.BacklogItem{}}, nil
 	}
 
-	data, err := os.ReadFile(filePath)
+	version, err := documentVersion(data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read backlog file: %w", err)
+		return nil, fmt.Errorf("failed to parse %s file: %w", what, err)
+	}
+	if err := checkVersion(version, name); err != nil {
+		return nil, err
 	}
+	if version < SchemaVersion {
+		if data, fp, err = s.upgradeFile(name); err != nil {
+			return nil, err
+		}
+	}
+
+	s.setLoaded(name, fp)
 
 	var backlog models.Backlog
 	if err := json.Unmarshal(data, &backlog); err != nil {
-		return nil, fmt.Errorf("failed to parse backlog file: %w", err)
+		return nil, fmt.Errorf("failed to parse %s file: %w", what, err)
 	}
 
 	return &backlog, nil
 }
 
-// Save writes the backlog to the JSON file
-func (s *Storage) Save(backlog *models.Backlog) error {
-	filePath := filepath.Join(s.dataDir, backlogFile)
+// upgradeFile migrates a data file written by an older schema in place,
+// after backing up the original
+func (s *JSONStore) upgradeFile(name string) ([]byte, fingerprint, error) {
+	filePath := filepath.Join(s.dataDir, name)
+
+	unlock, err := s.Lock()
+	if err != nil {
+		return nil, fingerprint{}, err
+	}
+	defer unlock()
+
+	// Re-read under the lock in case another process upgraded it first
+	data, fp, err := readFingerprinted(filePath)
+	if err != nil {
+		return nil, fingerprint{}, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	version, err := documentVersion(data)
+	if err != nil {
+		return nil, fingerprint{}, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	if version == SchemaVersion {
+		return data, fp, nil
+	}
 
+	upgraded, err := upgradeDocument(data, name, fileCollections[name])
+	if err != nil {
+		return nil, fingerprint{}, err
+	}
+
+	if err := backupFile(filePath, version); err != nil {
+		return nil, fingerprint{}, fmt.Errorf("failed to back up %s before upgrading it: %w", name, err)
+	}
+	if err := writeFileAtomic(filePath, upgraded, 0644); err != nil {
+		return nil, fingerprint{}, fmt.Errorf("failed to write upgraded %s: %w", name, err)
+	}
+
+	return upgraded, fingerprintOf(upgraded), nil
+}
+
+func (s *JSONStore) setLoaded(name string, fp fingerprint) {
+	s.mu.Lock()
+	s.loaded[name] = fp
+	s.mu.Unlock()
+}
+
+// writeFile atomically replaces one of the data files. It holds the
+// directory lock while it checks that the file is unchanged since this
+// store last read it, and fails with ErrConflict otherwise.
+func (s *JSONStore) writeFile(name, what string, backlog *models.Backlog) error {
+	filePath := filepath.Join(s.dataDir, name)
+
+	backlog.Version = SchemaVersion
 	data, err := json.MarshalIndent(backlog, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal backlog: %w", err)
+		return fmt.Errorf("failed to marshal %s: %w", what, err)
+	}
+
+	unlock, err := s.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, current, err := readFingerprinted(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s file: %w", what, err)
+	}
+	if seen, ok := s.loaded[name]; ok && current != seen {
+		return fmt.Errorf("%w: %s was modified by another process since it was loaded", ErrConflict, name)
+	}
+
+	// Never downgrade a file written by a newer build
+	if current.exists {
+		if version, err := documentVersion(existing); err == nil {
+			if err := checkVersion(version, name); err != nil {
+				return err
+			}
+		}
 	}
 
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write backlog file: %w", err)
+	if err := writeFileAtomic(filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s file: %w", what, err)
 	}
 
+	s.loaded[name] = fingerprintOf(data)
 	return nil
 }
 
-// LoadArchive reads the archived items from the JSON file
-func (s *Storage) LoadArchive() (*models.Backlog, error) {
-	filePath := filepath.Join(s.dataDir, archiveFile)
+// Get returns the active item with the given ID
+func (s *JSONStore) Get(id string) (*models.BacklogItem, error) {
+	backlog, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
 
-	// If file doesn't exist, return empty backlog
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return &models.Backlog{Items: []models.BacklogItem{}}, nil
+	i := findItem(backlog, id)
+	if i < 0 {
+		return nil, ErrNotFound
 	}
 
-	data, err := os.ReadFile(filePath)
+	return &backlog.Items[i], nil
+}
+
+// Put inserts the item, or replaces the active item with the same ID
+func (s *JSONStore) Put(item models.BacklogItem) error {
+	unlock, err := s.Lock()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read archive file: %w", err)
+		return err
 	}
+	defer unlock()
 
-	var backlog models.Backlog
-	if err := json.Unmarshal(data, &backlog); err != nil {
-		return nil, fmt.Errorf("failed to parse archive file: %w", err)
+	backlog, err := s.Load()
+	if err != nil {
+		return err
 	}
 
-	return &backlog, nil
+	if i := findItem(backlog, item.ID); i >= 0 {
+		backlog.Items[i] = item
+	} else {
+		backlog.Items = append(backlog.Items, item)
+	}
+
+	return s.Save(backlog)
 }
 
-// SaveArchive writes the archived items to the JSON file
-func (s *Storage) SaveArchive(backlog *models.Backlog) error {
-	filePath := filepath.Join(s.dataDir, archiveFile)
+// Delete removes the active item with the given ID
+func (s *JSONStore) Delete(id string) error {
+	unlock, err := s.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	data, err := json.MarshalIndent(backlog, "", "  ")
+	backlog, err := s.Load()
 	if err != nil {
-		return fmt.Errorf("failed to marshal archive: %w", err)
+		return err
 	}
 
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write archive file: %w", err)
+	i := findItem(backlog, id)
+	if i < 0 {
+		return ErrNotFound
 	}
+	backlog.Items = append(backlog.Items[:i], backlog.Items[i+1:]...)
 
-	return nil
+	return s.Save(backlog)
 }
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vvb/backlog/models"
+)
+
+func TestJSONStoreSaveDetectsStaleLoad(t *testing.T) {
+	dir := t.TempDir()
+	first, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stale, err := first.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := second.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	current.Items = append(current.Items, models.BacklogItem{ID: "a", Title: "Saved first"})
+	if err := second.Save(current); err != nil {
+		t.Fatal(err)
+	}
+
+	stale.Items = append(stale.Items, models.BacklogItem{ID: "b", Title: "Saved second"})
+	if err := first.Save(stale); !errors.Is(err, ErrConflict) {
+		t.Fatalf("saving a stale backlog: err = %v, want ErrConflict", err)
+	}
+
+	// The refused save left the other change in place, and loading again
+	// lets the store save
+	fresh, err := first.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fresh.Items) != 1 || fresh.Items[0].ID != "a" {
+		t.Fatalf("items after the refused save = %+v, want only the first change", fresh.Items)
+	}
+	if err := first.Save(fresh); err != nil {
+		t.Errorf("saving after reloading: %v", err)
+	}
+}
+
+func TestJSONStoreSaveDetectsOutsideEdit(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backlog, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveTrash(&models.Backlog{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, backlogFile), []byte(`{"items": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(backlog); !errors.Is(err, ErrConflict) {
+		t.Errorf("saving over an edited file: err = %v, want ErrConflict", err)
+	}
+}
+
+func TestWriteFileAtomicCleansUpOnFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	// Renaming a file over a non-empty directory fails after the temp
+	// file has been written
+	path := filepath.Join(dir, "items.json")
+	if err := os.MkdirAll(filepath.Join(path, "keep"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte("{}"), 0644); err == nil {
+		t.Fatal("writing over a directory succeeded")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "items.json" {
+			t.Errorf("left %s behind", entry.Name())
+		}
+	}
+}
+
+func TestWriteFileAtomicReplacesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.json")
+	for _, data := range []string{"first", "second"} {
+		if err := writeFileAtomic(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "second" {
+		t.Errorf("file holds %q, want %q", data, "second")
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v (%v), want 0600", info.Mode().Perm(), err)
+	}
+}
+
+func TestLockIsReentrantWithinProcess(t *testing.T) {
+	dir := t.TempDir()
+	first, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Stores on the same directory share the lock, and saving takes it
+	// again, so none of this may block
+	unlockFirst, err := first.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlockSecond, err := second.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backlog, err := second.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Save(backlog); err != nil {
+		t.Fatal(err)
+	}
+
+	lock := dirLockFor(dir)
+	if lock.depth != 2 {
+		t.Errorf("depth = %d while held twice, want 2", lock.depth)
+	}
+	unlockSecond()
+	unlockSecond() // releasing twice only releases once
+	if lock.depth != 1 || lock.file == nil {
+		t.Errorf("depth = %d after one release, want 1 with the file still locked", lock.depth)
+	}
+	unlockFirst()
+	if lock.depth != 0 || lock.file != nil {
+		t.Errorf("depth = %d after the last release, want the lock released", lock.depth)
+	}
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/vvb/backlog/models"
+)
+
+// ErrNotFound is returned by item-level operations when no item has the
+// requested ID
+var ErrNotFound = errors.New("item not found")
+
+// ErrConflict is returned by Save when the data on disk was changed by
+// another process after it was loaded. Reload and retry the change.
+var ErrConflict = errors.New("conflicting change")
+
+// Store is implemented by every storage backend. Commands and the
+// interactive model only talk to this interface, so new backends can be
+// added without touching them.
+type Store interface {
+	// Lock serializes read-modify-write cycles across processes. Hold it
+	// from Load to Save; the returned function releases it.
+	Lock() (unlock func(), err error)
+
+	// Load reads the active backlog
+	Load() (*models.Backlog, error)
+	// Save replaces the active backlog
+	Save(backlog *models.Backlog) error
+	// LoadArchive reads the archived items
+	LoadArchive() (*models.Backlog, error)
+	// SaveArchive replaces the archived items
+	SaveArchive(backlog *models.Backlog) error
+	// LoadTrash reads the deleted items
+	LoadTrash() (*models.Backlog, error)
+	// SaveTrash replaces the deleted items
+	SaveTrash(backlog *models.Backlog) error
+
+	// Get returns the active item with the given ID
+	Get(id string) (*models.BacklogItem, error)
+	// Put inserts the item, or replaces the active item with the same ID
+	Put(item models.BacklogItem) error
+	// Delete removes the active item with the given ID
+	Delete(id string) error
+}
+
+// findItem returns the index of the item with the given ID, or -1
+func findItem(backlog *models.Backlog, id string) int {
+	for i := range backlog.Items {
+		if backlog.Items[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+var (
+	_ Store = (*JSONStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*SQLiteStore)(nil)
+
+	_ Querier = (*SQLiteStore)(nil)
+)